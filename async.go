@@ -0,0 +1,198 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Bounds of the delay between two polls of an asynchronous call.
+const (
+	minPollDelay = 100 * time.Microsecond
+	maxPollDelay = 50 * time.Millisecond
+)
+
+// SetAsync makes the statement run asynchronously: instead of blocking a
+// thread for the whole call, the driver is polled until the call completes.
+// Execute, Fetch and NextResult check their context in between, the other
+// functions simply wait. It is a no-op when the driver cannot run single
+// statements asynchronously, see Capabilities.AsyncMode: drivers reporting
+// SQL_AM_CONNECTION only switch whole connections, and the statement then
+// runs synchronously.
+func (stmt *Statement) SetAsync(b bool) error {
+	if err := stmt.checkOpen("set async"); err != nil {
+		return err
+	}
+	if b == stmt.async || b && !stmt.conn.asyncSupported() {
+		return nil
+	}
+	var n uintptr = C.SQL_ASYNC_ENABLE_OFF
+
+	if b {
+		n = C.SQL_ASYNC_ENABLE_ON
+	}
+	if err := stmt.setAttr(C.SQL_ATTR_ASYNC_ENABLE, n); err != nil {
+		return err
+	}
+	stmt.async = b
+	return nil
+}
+
+// Async reports whether the statement runs asynchronously.
+func (stmt *Statement) Async() bool {
+	return stmt.async
+}
+
+// asyncSupported reports whether the driver runs statements asynchronously
+// one at a time.
+func (conn *Connection) asyncSupported() bool {
+	caps, err := conn.Capabilities()
+	if err != nil || caps.AsyncMode != C.SQL_AM_STATEMENT {
+		Logger().Debug("godbc: asynchronous statements not supported, running synchronously")
+		return false
+	}
+	return true
+}
+
+// poll runs call until it no longer returns SQL_STILL_EXECUTING. The
+// statement is cancelled once ctx is done; the driver then completes the
+// call with SQLSTATE HY008. A failure is returned as an error, read before
+// any other call clears the diagnostics.
+func (stmt *Statement) poll(ctx context.Context, call func() C.SQLRETURN) (C.SQLRETURN, error) {
+	if !stmt.async {
+		return stmt.result(call())
+	}
+	return stmt.result(pollUntilDone(ctx, call, func() {
+		C.SQLCancel(C.SQLHSTMT(stmt.handle))
+	}))
+}
+
+// wait runs call until it no longer returns SQL_STILL_EXECUTING, for the
+// functions of an asynchronous statement that take no context.
+func (stmt *Statement) wait(call func() C.SQLRETURN) C.SQLRETURN {
+	if !stmt.async {
+		return call()
+	}
+	return pollUntilDone(context.Background(), call, func() {})
+}
+
+// pollUntilDone calls call, with a growing delay in between, until it no
+// longer returns SQL_STILL_EXECUTING. cancel is called once when ctx is
+// done, call is then expected to complete soon with an error.
+func pollUntilDone(ctx context.Context, call func() C.SQLRETURN, cancel func()) C.SQLRETURN {
+	var (
+		delay    = minPollDelay
+		canceled bool
+	)
+
+	for {
+		ret := call()
+		if ret != C.SQL_STILL_EXECUTING {
+			return ret
+		}
+		if !canceled && ctx.Err() != nil {
+			cancel()
+			canceled = true
+		}
+
+		t := time.NewTimer(delay)
+		if canceled {
+			<-t.C
+		} else {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+			}
+		}
+		delay = min(2*delay, maxPollDelay)
+	}
+}
+
+func (stmt *Statement) result(ret C.SQLRETURN) (C.SQLRETURN, error) {
+	stmt.checkInfo(ret)
+	if ret == C.SQL_NO_DATA || ret == C.SQL_NEED_DATA || Success(ret) {
+		return ret, nil
+	}
+	return ret, stmt.error()
+}
+
+// errNoAsyncDbc tells driverConnect found the driver without
+// asynchronous connection functions.
+var errNoAsyncDbc = errors.New("godbc: asynchronous connection functions not supported")
+
+// enableAsyncDbc turns the asynchronous execution of the connection
+// functions of h on or off. It needs an ODBC 3.8 driver manager and
+// driver.
+func enableAsyncDbc(h C.SQLHANDLE, b bool) error {
+	if !odbc38.Load() {
+		return errors.New("godbc: asynchronous connection functions need ODBC 3.8")
+	}
+	var n uint = C.SQL_ASYNC_DBC_ENABLE_OFF
+
+	if b {
+		n = C.SQL_ASYNC_DBC_ENABLE_ON
+	}
+	_, err := setConnectAttr(h, C.SQL_ATTR_ASYNC_DBC_FUNCTIONS_ENABLE, n)
+	return err
+}
+
+// asyncDbcUnsupported reports whether err is the driver manager refusing
+// the asynchronous connection functions: HY114 when the driver does not
+// support them, HYC00 from drivers rejecting the attribute themselves.
+func asyncDbcUnsupported(err error) bool {
+	e, ok := err.(*Error)
+	return ok && (e.SQLState == "HY114" || e.SQLState == "HYC00")
+}
+
+// pollDbc runs the connection function call of h until it completes,
+// polling when async is true. h is cancelled once ctx is done.
+func pollDbc(ctx context.Context, h C.SQLHANDLE, async bool, call func() C.SQLRETURN) C.SQLRETURN {
+	if !async {
+		return call()
+	}
+	return pollUntilDone(ctx, call, func() {
+		C.SQLCancelHandle(C.SQL_HANDLE_DBC, h)
+	})
+}
+
+// Cancel aborts the connection function, such as Close, running
+// asynchronously in another goroutine. It needs an ODBC 3.8 driver.
+func (conn *Connection) Cancel() error {
+	if ret := C.SQLCancelHandle(C.SQL_HANDLE_DBC, conn.Dbc); !Success(ret) {
+		return conn.error()
+	}
+	return nil
+}
+
+// contextError ties err to the context error when the statement failed
+// because ctx was done, see ExecuteContext.
+func contextError(ctx context.Context, err error, timeoutFromCtx bool) error {
+	if e, ok := err.(*Error); ok {
+		switch {
+		case e.SQLState == "HYT00" && timeoutFromCtx:
+			e.err = context.DeadlineExceeded
+		case e.SQLState == "HY008" && ctx.Err() != nil:
+			e.err = ctx.Err()
+		}
+	}
+	return err
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Connection attributes for SetAttr and GetAttr. Driver specific
+// attributes can be used by number.
+const (
+	AttrAccessMode        = C.SQL_ATTR_ACCESS_MODE
+	AttrAutocommit        = C.SQL_ATTR_AUTOCOMMIT
+	AttrConnectionDead    = C.SQL_ATTR_CONNECTION_DEAD
+	AttrConnectionTimeout = C.SQL_ATTR_CONNECTION_TIMEOUT
+	AttrCurrentCatalog    = C.SQL_ATTR_CURRENT_CATALOG
+	AttrLoginTimeout      = C.SQL_ATTR_LOGIN_TIMEOUT
+	AttrMetadataID        = C.SQL_ATTR_METADATA_ID
+	AttrPacketSize        = C.SQL_ATTR_PACKET_SIZE
+	AttrQuietMode         = C.SQL_ATTR_QUIET_MODE
+	AttrTrace             = C.SQL_ATTR_TRACE
+	AttrTraceFile         = C.SQL_ATTR_TRACEFILE
+	AttrTranslateLib      = C.SQL_ATTR_TRANSLATE_LIB
+	AttrTranslateOption   = C.SQL_ATTR_TRANSLATE_OPTION
+	AttrTxnIsolation      = C.SQL_ATTR_TXN_ISOLATION
+)
+
+// stringAttrs lists the standard attributes holding a character string,
+// GetAttr returns the others as integers.
+var stringAttrs = map[int]bool{
+	AttrCurrentCatalog: true,
+	AttrTraceFile:      true,
+	AttrTranslateLib:   true,
+}
+
+// Transaction isolation levels, for SetTxnIsolation.
+const (
+	TxnReadUncommitted = C.SQL_TXN_READ_UNCOMMITTED
+	TxnReadCommitted   = C.SQL_TXN_READ_COMMITTED
+	TxnRepeatableRead  = C.SQL_TXN_REPEATABLE_READ
+	TxnSerializable    = C.SQL_TXN_SERIALIZABLE
+)
+
+// Attr is a connection attribute to set before the connection is
+// established. Pass it to Connect or ConnectContext after the connection
+// string:
+//
+//	conn, err := godbc.Connect("DSN=test", godbc.Attr{godbc.AttrPacketSize, 8192})
+type Attr struct {
+	ID    int
+	Value interface{} // as accepted by SetAttr.
+}
+
+// SetAttr sets the connection attribute attr. value is an integer, a bool,
+// a string or an unsafe.Pointer to C memory, depending on the attribute.
+func (conn *Connection) SetAttr(attr int, value interface{}) error {
+	ret, err := setConnectAttr(conn.Dbc, attr, value)
+	if err != nil {
+		return err
+	}
+	conn.checkInfo(ret, C.SQL_HANDLE_DBC, conn.Dbc)
+	return nil
+}
+
+// GetAttr returns the value of the connection attribute attr, as a string
+// for the standard string attributes and as an uint64 otherwise. Use
+// GetAttrString for driver specific string attributes.
+func (conn *Connection) GetAttr(attr int) (interface{}, error) {
+	if stringAttrs[attr] {
+		return conn.GetAttrString(attr)
+	}
+	return conn.GetAttrInt(attr)
+}
+
+// GetAttrInt returns the value of the integer connection attribute attr.
+func (conn *Connection) GetAttrInt(attr int) (uint64, error) {
+	var value C.SQLULEN
+
+	if ret := C.SQLGetConnectAttr(
+		C.SQLHDBC(conn.Dbc),
+		C.SQLINTEGER(attr),
+		C.SQLPOINTER(unsafe.Pointer(&value)),
+		C.SQL_IS_UINTEGER,
+		nil); !Success(ret) {
+		return 0, conn.error()
+	}
+	return uint64(value), nil
+}
+
+// GetAttrString returns the value of the string connection attribute attr.
+func (conn *Connection) GetAttrString(attr int) (string, error) {
+	var (
+		valueLen C.SQLINTEGER
+		value    = make([]uint16, infoBufferLen)
+		ansi     []byte
+	)
+
+	for {
+		if ret := conn.callW(C.SQL_HANDLE_DBC, conn.Dbc, func() C.SQLRETURN {
+			return C.SQLGetConnectAttrW(
+				C.SQLHDBC(conn.Dbc),
+				C.SQLINTEGER(attr),
+				C.SQLPOINTER(unsafe.Pointer(&value[0])),
+				C.SQLINTEGER(len(value)*2),
+				&valueLen)
+		}, func() C.SQLRETURN {
+			ansi = make([]byte, len(value)*2)
+			return C.SQLGetConnectAttr(
+				C.SQLHDBC(conn.Dbc),
+				C.SQLINTEGER(attr),
+				C.SQLPOINTER(unsafe.Pointer(&ansi[0])),
+				C.SQLINTEGER(len(ansi)),
+				&valueLen)
+		}); !Success(ret) {
+			return "", conn.error()
+		}
+		if ansi != nil {
+			if int(valueLen) < len(ansi) {
+				return string(ansi[:valueLen]), nil
+			}
+			// The string is longer than the buffer.
+			value = make([]uint16, int(valueLen)/2+1)
+			continue
+		}
+		if int(valueLen)/2 < len(value) {
+			return UTF16ToString(value[:valueLen/2]), nil
+		}
+		value = make([]uint16, int(valueLen)/2+1)
+	}
+}
+
+// SetCurrentCatalog switches the connection to the given catalog
+// (database).
+func (conn *Connection) SetCurrentCatalog(catalog string) error {
+	return conn.SetAttr(AttrCurrentCatalog, catalog)
+}
+
+// CurrentCatalog returns the connection's current catalog.
+func (conn *Connection) CurrentCatalog() (string, error) {
+	return conn.GetAttrString(AttrCurrentCatalog)
+}
+
+// SetPacketSize sets the network packet size in bytes. Most drivers only
+// accept it before connecting, see Attr.
+func (conn *Connection) SetPacketSize(n int) error {
+	return conn.SetAttr(AttrPacketSize, n)
+}
+
+// PacketSize returns the network packet size in bytes.
+func (conn *Connection) PacketSize() (int, error) {
+	n, err := conn.GetAttrInt(AttrPacketSize)
+	return int(n), err
+}
+
+// SetTxnIsolation sets the transaction isolation level, one of the Txn*
+// constants.
+func (conn *Connection) SetTxnIsolation(level int) error {
+	return conn.SetAttr(AttrTxnIsolation, level)
+}
+
+// TxnIsolation returns the transaction isolation level.
+func (conn *Connection) TxnIsolation() (int, error) {
+	n, err := conn.GetAttrInt(AttrTxnIsolation)
+	return int(n), err
+}
+
+// SetReadOnly sets the SQL_ATTR_ACCESS_MODE of the connection. It is a hint
+// the driver may use to optimize, not an enforced restriction.
+func (conn *Connection) SetReadOnly(b bool) error {
+	var mode = C.SQL_MODE_READ_WRITE
+
+	if b {
+		mode = C.SQL_MODE_READ_ONLY
+	}
+	return conn.SetAttr(AttrAccessMode, mode)
+}
+
+// ReadOnly reports whether the connection's access mode is read-only.
+func (conn *Connection) ReadOnly() (bool, error) {
+	n, err := conn.GetAttrInt(AttrAccessMode)
+	return n == C.SQL_MODE_READ_ONLY, err
+}
+
+// SetTrace turns driver manager tracing on or off.
+func (conn *Connection) SetTrace(b bool) error {
+	return conn.SetAttr(AttrTrace, b)
+}
+
+// SetTraceFile sets the file driver manager tracing writes to.
+func (conn *Connection) SetTraceFile(path string) error {
+	return conn.SetAttr(AttrTraceFile, path)
+}
+
+func setConnectAttr(h C.SQLHANDLE, attr int, value interface{}) (C.SQLRETURN, error) {
+	var (
+		ptr    C.SQLPOINTER
+		length C.SQLINTEGER
+	)
+
+	switch v := value.(type) {
+	case int:
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(uintptr(v))), C.SQL_IS_INTEGER
+	case int32:
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(uintptr(v))), C.SQL_IS_INTEGER
+	case int64:
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(uintptr(v))), C.SQL_IS_INTEGER
+	case uint:
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(uintptr(v))), C.SQL_IS_UINTEGER
+	case uint32:
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(uintptr(v))), C.SQL_IS_UINTEGER
+	case uint64:
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(uintptr(v))), C.SQL_IS_UINTEGER
+	case bool:
+		var n uintptr = C.SQL_FALSE
+		if v {
+			n = C.SQL_TRUE
+		}
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(n)), C.SQL_IS_UINTEGER
+	case string:
+		s := StringToUTF16(v)
+		ptr, length = C.SQLPOINTER(unsafe.Pointer(&s[0])), C.SQLINTEGER((len(s)-1)*2)
+		ret := C.SQLSetConnectAttrW(C.SQLHDBC(h), C.SQLINTEGER(attr), ptr, length)
+		if ret == C.SQL_ERROR && diagState(C.SQL_HANDLE_DBC, h) == "IM001" {
+			// The driver lacks the W functions.
+			b := []byte(v + "\x00")
+			ret = C.SQLSetConnectAttr(C.SQLHDBC(h), C.SQLINTEGER(attr), C.SQLPOINTER(unsafe.Pointer(&b[0])), C.SQLINTEGER(len(v)))
+		}
+		if !Success(ret) {
+			return ret, FormatError(C.SQL_HANDLE_DBC, h)
+		}
+		return ret, nil
+	case unsafe.Pointer:
+		ptr, length = C.SQLPOINTER(v), C.SQL_IS_POINTER
+	default:
+		return C.SQL_ERROR, fmt.Errorf("godbc: unsupported value type %T for connection attribute %d", value, attr)
+	}
+
+	ret := C.SQLSetConnectAttr(C.SQLHDBC(h), C.SQLINTEGER(attr), ptr, length)
+	if !Success(ret) {
+		return ret, FormatError(C.SQL_HANDLE_DBC, h)
+	}
+	return ret, nil
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"errors"
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// BrowseAttribute is a connection attribute requested by the driver
+// during a BrowseConnect session.
+type BrowseAttribute struct {
+	Keyword  string
+	Label    string   // human readable name, may be empty.
+	Optional bool     // the attribute may be left out.
+	Values   []string // the valid values, nil if any value is accepted.
+}
+
+// Browser is an interactive connection session, see BrowseConnect.
+type Browser struct {
+	h      C.SQLHANDLE
+	cfg    *config
+	onInfo func(*Diagnostic)
+	attrs  []*BrowseAttribute
+	conn   *Connection
+}
+
+// BrowseConnect starts discovering the attributes needed to connect with
+// the connection string in, typically just "DSN=name" or "DRIVER={name}".
+// The attributes the driver needs next are listed by Attributes; answer them
+// with Next until Connection returns the established connection. Package
+// options and params are those of Connect, they can only be given here.
+func BrowseConnect(in string, params ...interface{}) (*Browser, error) {
+	in, cfg, err := splitOptions(in)
+	if err != nil {
+		return nil, err
+	}
+	b := &Browser{cfg: cfg}
+
+	if b.h, b.onInfo, err = allocConn(cfg.loginTimeout, cfg.connectionTimeout, params); err != nil {
+		return nil, err
+	}
+	if err := b.Next(in); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Attributes returns the attributes the driver needs to go on with the
+// connection. It is empty once connected.
+func (b *Browser) Attributes() []*BrowseAttribute {
+	return b.attrs
+}
+
+// Next sends the answers in, a connection string holding values for the
+// attributes returned by Attributes, and moves the session on.
+func (b *Browser) Next(in string) error {
+	if b.conn != nil {
+		return errors.New("godbc: browse session already connected")
+	}
+
+	var (
+		outLen C.SQLSMALLINT
+		outBuf = make([]byte, math.MaxInt16)
+		cin    = append([]byte(in), 0)
+	)
+	ret := C.SQLBrowseConnect(
+		C.SQLHDBC(b.h),
+		(*C.SQLCHAR)(unsafe.Pointer(&cin[0])),
+		C.SQL_NTS,
+		(*C.SQLCHAR)(unsafe.Pointer(&outBuf[0])),
+		C.SQLSMALLINT(len(outBuf)),
+		&outLen)
+	if ret != C.SQL_NEED_DATA && !Success(ret) {
+		return FormatError(C.SQL_HANDLE_DBC, b.h)
+	}
+	if int(outLen) >= len(outBuf) {
+		outLen = C.SQLSMALLINT(len(outBuf) - 1)
+	}
+	out := string(outBuf[:outLen])
+
+	if ret == C.SQL_NEED_DATA {
+		b.attrs = parseBrowseResult(out)
+		return nil
+	}
+	b.attrs = nil
+	b.conn = &Connection{Dbc: b.h, connected: true, outConnStr: out, cfg: b.cfg, onInfo: b.onInfo}
+	var info []*Diagnostic
+	if ret == C.SQL_SUCCESS_WITH_INFO && b.conn.wantsInfo() {
+		info = diagnostics(C.SQL_HANDLE_DBC, b.h)
+	}
+	b.conn.setup(info)
+	return nil
+}
+
+// Connection returns the established connection, or nil while the driver
+// still needs attributes. The connection is owned by the caller.
+func (b *Browser) Connection() *Connection {
+	return b.conn
+}
+
+// Close abandons the session if it has not connected yet.
+func (b *Browser) Close() error {
+	if b.conn != nil || b.h == nil {
+		return nil
+	}
+	// SQLDisconnect ends an unfinished browse session.
+	C.SQLDisconnect(C.SQLHDBC(b.h))
+	if ret := C.SQLFreeHandle(C.SQL_HANDLE_DBC, b.h); !Success(ret) {
+		return FormatError(C.SQL_HANDLE_DBC, b.h)
+	}
+	b.h = nil
+	return nil
+}
+
+// parseBrowseResult parses the SQLBrowseConnect output, a list of
+// "[*]KEYWORD[:Label]=?" or "[*]KEYWORD[:Label]={value,value}" separated by
+// semicolons.
+func parseBrowseResult(s string) []*BrowseAttribute {
+	var attrs []*BrowseAttribute
+
+	for len(s) > 0 {
+		a := &BrowseAttribute{}
+		if s[0] == '*' {
+			a.Optional = true
+			s = s[1:]
+		}
+
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			break
+		}
+		a.Keyword, a.Label, _ = strings.Cut(s[:i], ":")
+		s = s[i+1:]
+
+		var value string
+		if strings.HasPrefix(s, "{") {
+			j := strings.IndexByte(s, '}')
+			if j < 0 {
+				s += "}"
+				j = len(s) - 1
+			}
+			value, s = s[1:j], s[j+1:]
+			a.Values = strings.Split(value, ",")
+		} else {
+			j := strings.IndexByte(s, ';')
+			if j < 0 {
+				j = len(s)
+			}
+			value, s = s[:j], s[j:]
+			if value != "?" {
+				a.Values = []string{value}
+			}
+		}
+		s = strings.TrimPrefix(s, ";")
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
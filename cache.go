@@ -0,0 +1,188 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// CacheStats are the statistics of a connection's statement cache.
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+}
+
+// stmtCache keeps released prepared statements by SQL text, the most
+// recently used at the front. A statement in use is not in the cache. mu
+// guards the fields below it: errors reach the cache from any goroutine
+// reading them.
+type stmtCache struct {
+	size  int
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	gen   int // bumped by invalidate, statements of older ones are dropped.
+	stats CacheStats
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// PrepareCached is like Prepare but takes the statement from the
+// connection's cache when sql was prepared before. Give the statement back
+// with Release rather than Close. The cache is sized with the
+// godbc.stmt_cache_size option, without it PrepareCached is Prepare.
+func (conn *Connection) PrepareCached(sql string) (*Statement, error) {
+	c := conn.stmtCache
+	if c == nil {
+		return conn.Prepare(sql)
+	}
+	c.mu.Lock()
+	if e, ok := c.items[sql]; ok {
+		c.ll.Remove(e)
+		delete(c.items, sql)
+		c.stats.Hits++
+		c.mu.Unlock()
+		return e.Value.(*Statement), nil
+	}
+	c.stats.Misses++
+	gen := c.gen
+	c.mu.Unlock()
+
+	stmt, err := conn.Prepare(sql)
+	if err != nil {
+		return nil, err
+	}
+	stmt.cacheKey, stmt.cacheGen = sql, gen
+	return stmt, nil
+}
+
+// CacheStats returns the statistics of the statement cache.
+func (conn *Connection) CacheStats() CacheStats {
+	c := conn.stmtCache
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// InvalidateStmtCache closes the statements of the cache. Those in use
+// are closed when released instead of going back to it.
+func (conn *Connection) InvalidateStmtCache() {
+	if conn.stmtCache != nil {
+		conn.stmtCache.invalidate()
+	}
+}
+
+// Release gives a statement obtained from PrepareCached back to the cache,
+// closing its cursor and unbinding its parameters. Other statements are
+// closed.
+func (stmt *Statement) Release() {
+	c := stmt.conn.stmtCache
+	if c == nil || stmt.cacheKey == "" {
+		stmt.Close()
+		return
+	}
+	// Reset before locking, its errors may invalidate the cache.
+	if err := stmt.Reset(); err != nil {
+		Logger().Debug("godbc: cannot reset cached statement", "err", err)
+		stmt.Close()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt.cacheGen != c.gen {
+		stmt.Close()
+		return
+	}
+	if _, ok := c.items[stmt.cacheKey]; ok {
+		// The same SQL was prepared twice, keep the cached one.
+		stmt.Close()
+		return
+	}
+	c.items[stmt.cacheKey] = c.ll.PushFront(stmt)
+	for c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		old := e.Value.(*Statement)
+		delete(c.items, old.cacheKey)
+		old.Close()
+		c.stats.Evictions++
+	}
+}
+
+// invalidate closes the cached statements and drops those in use when
+// they are released. The latter are never closed here, their owner may be
+// running them.
+func (c *stmtCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.ll.Front(); e != nil; e = e.Next() {
+		e.Value.(*Statement).Close()
+	}
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+	c.gen++
+}
+
+// checkError invalidates the statement cache after an error telling that
+// the connection was lost or that prepared statements may be stale.
+func (conn *Connection) checkError(err error) {
+	if errors.Is(err, ErrConnectionLost) {
+		conn.lost.Store(true)
+	}
+	if conn.stmtCache != nil && (conn.lost.Load() || schemaChanged(conn.dbms, err)) {
+		Logger().Debug("godbc: invalidating the statement cache", "err", err)
+		conn.stmtCache.invalidate()
+	}
+}
+
+// Lost reports whether an error told that the connection was lost.
+func (conn *Connection) Lost() bool {
+	return conn.lost.Load()
+}
+
+// schemaChanged reports errors caused by the database objects a prepared
+// statement refers to having changed since it was prepared.
+func schemaChanged(dbms dbmsKind, err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, d := range e.records() {
+		switch d.SQLState {
+		case "42S02", "42S22": // table or column not found.
+			return true
+		}
+		switch {
+		case dbms == dbmsSQLServer && d.NativeError == 16943, // table schema changed.
+			dbms == dbmsOracle && (d.NativeError == 4061 || d.NativeError == 4068),
+			dbms == dbmsPostgreSQL && d.SQLState == "0A000": // cached plan must not change result type.
+			return true
+		}
+	}
+	return false
+}
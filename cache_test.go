@@ -0,0 +1,59 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestInvalidateStmtCache(t *testing.T) {
+	conn := &Connection{stmtCache: newStmtCache(4)}
+	c := conn.stmtCache
+
+	cached := &Statement{conn: conn, cacheKey: "SELECT 1", cacheGen: c.gen}
+	c.items[cached.cacheKey] = c.ll.PushFront(cached)
+	inUse := &Statement{conn: conn, cacheKey: "SELECT 2", cacheGen: c.gen}
+
+	conn.InvalidateStmtCache()
+	if !cached.closed {
+		t.Error("cached statement not closed")
+	}
+	if inUse.closed {
+		t.Error("statement in use closed")
+	}
+	if c.ll.Len() != 0 || len(c.items) != 0 {
+		t.Errorf("cache holds %d statements after invalidation", c.ll.Len())
+	}
+
+	// Released after the invalidation, it must not go back to the cache.
+	inUse.Release()
+	if !inUse.closed {
+		t.Error("stale statement not closed on release")
+	}
+	if _, ok := c.items[inUse.cacheKey]; ok {
+		t.Error("stale statement back in the cache")
+	}
+}
+
+func TestStmtCacheConcurrentInvalidation(t *testing.T) {
+	conn := &Connection{stmtCache: newStmtCache(4)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				conn.checkError(&Error{SQLState: "08S01"})
+				conn.CacheStats()
+			}
+		}()
+	}
+	wg.Wait()
+	if !conn.Lost() {
+		t.Error("connection not marked lost")
+	}
+}
@@ -0,0 +1,761 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"database/sql"
+	"unsafe"
+)
+
+// Table describes a table, view or other relation returned by SQLTables.
+type Table struct {
+	Catalog string
+	Schema  string
+	Name    string
+	Type    string // TABLE, VIEW, SYSTEM TABLE, ... as reported by the driver.
+	Remarks string
+}
+
+// Column describes a table column returned by SQLColumns.
+type Column struct {
+	Catalog         string
+	Schema          string
+	Table           string
+	Name            string
+	DataType        int    // SQL data type code.
+	TypeName        string // DBMS specific type name.
+	Size            int
+	BufferLength    int
+	DecimalDigits   int
+	Radix           int
+	Nullable        int // SQL_NO_NULLS, SQL_NULLABLE or SQL_NULLABLE_UNKNOWN.
+	Remarks         string
+	Default         sql.NullString
+	SQLDataType     int
+	DatetimeSubtype int
+	CharOctetLength int
+	Ordinal         int // 1-based position of the column in the table.
+	IsNullable      string
+}
+
+// Tables lists the tables matching the given catalog, schema and table
+// name. Unless SetMetadataID(true) was called, schema and name are search
+// patterns ('%' and '_' wildcards) and an empty argument matches
+// everything. After SetMetadataID(true) they are identifiers and must be
+// given, drivers reject them empty with SQLSTATE HY009. types is a comma
+// separated list of table types such as "TABLE,VIEW".
+func (conn *Connection) Tables(catalog, schema, name, types string) ([]*Table, error) {
+	var (
+		cat    = catalogArg(catalog)
+		sch    = catalogArg(schema)
+		tab    = catalogArg(name)
+		typ    = catalogArg(types)
+		tables []*Table
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLTablesW(h, cat.w, cat.n, sch.w, sch.n, tab.w, tab.n, typ.w, typ.n)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLTables(h, cat.a, cat.n, sch.a, sch.n, tab.a, tab.n, typ.a, typ.n)
+	}, func(stmt *Statement) error {
+		t := &Table{}
+		if err := stmt.scan(&t.Catalog, &t.Schema, &t.Name, &t.Type, &t.Remarks); err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	return tables, err
+}
+
+// Columns lists the columns of the tables matching catalog, schema and
+// table. schema, table and column are search patterns, an empty one
+// matching everything, unless SetMetadataID(true) was called: they are
+// then identifiers and must be given, as for Tables.
+func (conn *Connection) Columns(catalog, schema, table, column string) ([]*Column, error) {
+	var (
+		cat     = catalogArg(catalog)
+		sch     = catalogArg(schema)
+		tab     = catalogArg(table)
+		col     = catalogArg(column)
+		columns []*Column
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLColumnsW(h, cat.w, cat.n, sch.w, sch.n, tab.w, tab.n, col.w, col.n)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLColumns(h, cat.a, cat.n, sch.a, sch.n, tab.a, tab.n, col.a, col.n)
+	}, func(stmt *Statement) error {
+		c := &Column{}
+		if err := stmt.scan(
+			&c.Catalog, &c.Schema, &c.Table, &c.Name,
+			&c.DataType, &c.TypeName, &c.Size, &c.BufferLength,
+			&c.DecimalDigits, &c.Radix, &c.Nullable, &c.Remarks,
+			&c.Default, &c.SQLDataType, &c.DatetimeSubtype, &c.CharOctetLength,
+			&c.Ordinal, &c.IsNullable); err != nil {
+			return err
+		}
+		columns = append(columns, c)
+		return nil
+	})
+	return columns, err
+}
+
+// SetMetadataID controls how the catalog functions interpret their string
+// arguments. When true they are treated as case-insensitive identifiers
+// (or case-sensitive when quoted) instead of search patterns.
+func (conn *Connection) SetMetadataID(b bool) error {
+	var n C.SQLULEN = C.SQL_FALSE
+
+	if b {
+		n = C.SQL_TRUE
+	}
+	if ret := C.SQLSetConnectAttr(
+		C.SQLHDBC(conn.Dbc),
+		C.SQL_ATTR_METADATA_ID,
+		C.SQLPOINTER(unsafe.Pointer(uintptr(n))),
+		C.SQL_IS_UINTEGER); !Success(ret) {
+		return conn.error()
+	}
+	return nil
+}
+
+// EscapePattern escapes the search pattern wildcards in s so that it
+// matches only itself when given to one of the catalog functions.
+func (conn *Connection) EscapePattern(s string) (string, error) {
+	esc, err := conn.getInfoString(InfoSearchPatternEscape)
+	if err != nil {
+		return "", err
+	}
+	if esc == "" {
+		return s, nil
+	}
+
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '%' || c == '_' || string(c) == esc {
+			out = append(out, esc...)
+		}
+		out = append(out, s[i])
+	}
+	return string(out), nil
+}
+
+// catalog runs the catalog function call on a fresh statement and invokes
+// row for each row of the result set. ansi is the same call through the
+// ANSI function, for drivers without the W ones. Catalog result sets are
+// read by column position, not by name, as drivers do not agree on the
+// labels.
+func (conn *Connection) catalog(call, ansi func(C.SQLHSTMT) C.SQLRETURN, row func(*Statement) error) error {
+	stmt, err := conn.newStmt()
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if ret := stmt.callW(func() C.SQLRETURN {
+		return call(C.SQLHSTMT(stmt.handle))
+	}, func() C.SQLRETURN {
+		return ansi(C.SQLHSTMT(stmt.handle))
+	}); !Success(ret) {
+		return stmt.error()
+	}
+	stmt.executed, stmt.cursorOpen = true, true
+	for {
+		ok, err := stmt.Fetch()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := row(stmt); err != nil {
+			return err
+		}
+	}
+}
+
+// catArg is a catalog function argument, in both the wide and the ANSI
+// form, and its length.
+type catArg struct {
+	w *C.SQLWCHAR
+	a *C.SQLCHAR
+	n C.SQLSMALLINT
+}
+
+// catalogArg converts s to a catalog function argument. The empty string
+// is passed as NULL, which the catalog functions treat as "any" for
+// pattern arguments.
+func catalogArg(s string) catArg {
+	if s == "" {
+		return catArg{}
+	}
+	w, a := StringToUTF16(s), []byte(s+"\x00")
+	return catArg{
+		w: (*C.SQLWCHAR)(unsafe.Pointer(&w[0])),
+		a: (*C.SQLCHAR)(unsafe.Pointer(&a[0])),
+		n: C.SQL_NTS,
+	}
+}
+
+// scan reads the columns of the current row, in order, into dest.
+// Supported destinations are *string, *sql.NullString, *int, *int64,
+// *sql.NullInt64 and *bool; a nil entry skips the column. NULL values
+// leave the zero value.
+func (stmt *Statement) scan(dest ...interface{}) error {
+	for i, d := range dest {
+		col := i + 1
+		switch d := d.(type) {
+		case nil:
+		case *string:
+			s, _, err := stmt.getString(col)
+			if err != nil {
+				return err
+			}
+			*d = s
+		case *sql.NullString:
+			s, ok, err := stmt.getString(col)
+			if err != nil {
+				return err
+			}
+			*d = sql.NullString{String: s, Valid: ok}
+		case *int:
+			n, _, err := stmt.getInt(col)
+			if err != nil {
+				return err
+			}
+			*d = int(n)
+		case *int64:
+			n, _, err := stmt.getInt(col)
+			if err != nil {
+				return err
+			}
+			*d = n
+		case *sql.NullInt64:
+			n, ok, err := stmt.getInt(col)
+			if err != nil {
+				return err
+			}
+			*d = sql.NullInt64{Int64: n, Valid: ok}
+		case *bool:
+			n, _, err := stmt.getInt(col)
+			if err != nil {
+				return err
+			}
+			*d = n != 0
+		}
+	}
+	return nil
+}
+
+// getString reads column col of the current row as a string. The boolean
+// is false if the value is NULL.
+func (stmt *Statement) getString(col int) (string, bool, error) {
+	var (
+		ind C.SQLLEN
+		buf = make([]uint16, infoBufferLen)
+		out []uint16
+	)
+
+	for {
+		ret := stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
+				C.SQLHSTMT(stmt.handle),
+				C.SQLUSMALLINT(col),
+				C.SQL_C_WCHAR,
+				C.SQLPOINTER(unsafe.Pointer(&buf[0])),
+				C.SQLLEN(len(buf)*2),
+				&ind)
+		})
+		if ret == C.SQL_NO_DATA {
+			break
+		}
+		if !Success(ret) {
+			return "", false, stmt.error()
+		}
+		if ind == C.SQL_NULL_DATA {
+			return "", false, nil
+		}
+		if ind != C.SQL_NO_TOTAL && int(ind)/2 < len(buf) {
+			out = append(out, buf[:int(ind)/2]...)
+			break
+		}
+		// Truncated: the buffer holds len(buf)-1 characters and a NUL.
+		out = append(out, buf[:len(buf)-1]...)
+		if rest := int(ind)/2 - (len(buf) - 1); ind != C.SQL_NO_TOTAL && rest >= len(buf) {
+			buf = make([]uint16, rest+1)
+		}
+	}
+	return UTF16ToString(out), true, nil
+}
+
+// getInt reads column col of the current row as an integer. The boolean
+// is false if the value is NULL.
+func (stmt *Statement) getInt(col int) (int64, bool, error) {
+	var (
+		value C.longlong
+		ind   C.SQLLEN
+	)
+
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLGetData(
+			C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(col),
+			C.SQL_C_SBIGINT,
+			C.SQLPOINTER(unsafe.Pointer(&value)),
+			0,
+			&ind)
+	}); !Success(ret) {
+		return 0, false, stmt.error()
+	}
+	if ind == C.SQL_NULL_DATA {
+		return 0, false, nil
+	}
+	return int64(value), true, nil
+}
+
+// KeyColumn is one column of a primary key, in key order.
+type KeyColumn struct {
+	Catalog string
+	Schema  string
+	Table   string
+	Column  string
+	Seq     int // 1-based position of the column in the key.
+	KeyName string
+}
+
+// ReferentialAction is the action taken on the referencing rows when the
+// referenced key is updated or deleted.
+type ReferentialAction int
+
+// Referential actions, as returned in ForeignKey.UpdateRule and DeleteRule.
+const (
+	Cascade    ReferentialAction = C.SQL_CASCADE
+	Restrict   ReferentialAction = C.SQL_RESTRICT
+	SetNull    ReferentialAction = C.SQL_SET_NULL
+	NoAction   ReferentialAction = C.SQL_NO_ACTION
+	SetDefault ReferentialAction = C.SQL_SET_DEFAULT
+)
+
+func (a ReferentialAction) String() string {
+	switch a {
+	case Cascade:
+		return "CASCADE"
+	case Restrict:
+		return "RESTRICT"
+	case SetNull:
+		return "SET NULL"
+	case NoAction:
+		return "NO ACTION"
+	case SetDefault:
+		return "SET DEFAULT"
+	}
+	return "UNKNOWN"
+}
+
+// ForeignKey describes a foreign key relationship between two tables.
+type ForeignKey struct {
+	Name          string
+	PKName        string
+	PKCatalog     string
+	PKSchema      string
+	PKTable       string
+	FKCatalog     string
+	FKSchema      string
+	FKTable       string
+	Columns       []ForeignKeyColumn // in key order.
+	UpdateRule    ReferentialAction
+	DeleteRule    ReferentialAction
+	Deferrability int
+}
+
+// ForeignKeyColumn pairs a referencing column with the column it references.
+type ForeignKeyColumn struct {
+	FKColumn string
+	PKColumn string
+}
+
+// foreignKeyID identifies a foreign key among the rows of SQLForeignKeys.
+type foreignKeyID struct {
+	fkCatalog, fkSchema, fkTable string
+	pkCatalog, pkSchema, pkTable string
+	name                         string
+}
+
+// Index types, as returned in Index.Type.
+const (
+	IndexClustered = C.SQL_INDEX_CLUSTERED
+	IndexHashed    = C.SQL_INDEX_HASHED
+	IndexOther     = C.SQL_INDEX_OTHER
+)
+
+// Index describes a table index returned by SQLStatistics.
+type Index struct {
+	Catalog     string
+	Schema      string
+	Table       string
+	Qualifier   string
+	Name        string
+	Unique      bool
+	Type        int           // IndexClustered, IndexHashed or IndexOther.
+	Columns     []IndexColumn // in index order.
+	Cardinality int64
+	Pages       int64
+	Filter      string
+}
+
+// IndexColumn is one column, or expression, of an index.
+type IndexColumn struct {
+	Name       string
+	Descending bool
+}
+
+// SpecialColumn is a column returned by SQLSpecialColumns.
+type SpecialColumn struct {
+	Scope         int
+	Name          string
+	DataType      int
+	TypeName      string
+	Size          int
+	BufferLength  int
+	DecimalDigits int
+	PseudoColumn  int // SQL_PC_UNKNOWN, SQL_PC_NOT_PSEUDO or SQL_PC_PSEUDO.
+}
+
+// PrimaryKeys returns the primary key columns of the given table, in key
+// order. The arguments are not search patterns.
+func (conn *Connection) PrimaryKeys(catalog, schema, table string) ([]*KeyColumn, error) {
+	var (
+		cat  = catalogArg(catalog)
+		sch  = catalogArg(schema)
+		tab  = catalogArg(table)
+		keys []*KeyColumn
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLPrimaryKeysW(h, cat.w, cat.n, sch.w, sch.n, tab.w, tab.n)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLPrimaryKeys(h, cat.a, cat.n, sch.a, sch.n, tab.a, tab.n)
+	}, func(stmt *Statement) error {
+		k := &KeyColumn{}
+		if err := stmt.scan(&k.Catalog, &k.Schema, &k.Table, &k.Column, &k.Seq, &k.KeyName); err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	return keys, err
+}
+
+// ForeignKeys returns the foreign keys of fkTable when only the foreign key
+// table is given, the foreign keys referencing pkTable when only the primary
+// key table is given, or the foreign keys of fkTable referencing pkTable when
+// both are.
+func (conn *Connection) ForeignKeys(pkCatalog, pkSchema, pkTable, fkCatalog, fkSchema, fkTable string) ([]*ForeignKey, error) {
+	var (
+		pkCat   = catalogArg(pkCatalog)
+		pkSch   = catalogArg(pkSchema)
+		pkTab   = catalogArg(pkTable)
+		fkCat   = catalogArg(fkCatalog)
+		fkSch   = catalogArg(fkSchema)
+		fkTab   = catalogArg(fkTable)
+		keys    []*ForeignKey
+		named   = map[foreignKeyID]*ForeignKey{}
+		unnamed *ForeignKey
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLForeignKeysW(h,
+			pkCat.w, pkCat.n, pkSch.w, pkSch.n, pkTab.w, pkTab.n,
+			fkCat.w, fkCat.n, fkSch.w, fkSch.n, fkTab.w, fkTab.n)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLForeignKeys(h,
+			pkCat.a, pkCat.n, pkSch.a, pkSch.n, pkTab.a, pkTab.n,
+			fkCat.a, fkCat.n, fkSch.a, fkSch.n, fkTab.a, fkTab.n)
+	}, func(stmt *Statement) error {
+		var (
+			k                ForeignKey
+			col              ForeignKeyColumn
+			seq, update, del int
+		)
+		if err := stmt.scan(
+			&k.PKCatalog, &k.PKSchema, &k.PKTable, &col.PKColumn,
+			&k.FKCatalog, &k.FKSchema, &k.FKTable, &col.FKColumn,
+			&seq, &update, &del, &k.Name,
+			&k.PKName, &k.Deferrability); err != nil {
+			return err
+		}
+		k.UpdateRule, k.DeleteRule = ReferentialAction(update), ReferentialAction(del)
+		// Rows come ordered by table and key sequence, so the columns of
+		// keys between the same tables are interleaved: group them by
+		// name. Only unnamed keys rely on the sequence, a new key
+		// starting at 1.
+		var key *ForeignKey
+		if k.Name != "" {
+			id := foreignKeyID{
+				k.FKCatalog, k.FKSchema, k.FKTable,
+				k.PKCatalog, k.PKSchema, k.PKTable, k.Name,
+			}
+			if key = named[id]; key == nil {
+				key = &k
+				named[id] = key
+				keys = append(keys, key)
+			}
+		} else {
+			if seq <= 1 || unnamed == nil {
+				unnamed = &k
+				keys = append(keys, unnamed)
+			}
+			key = unnamed
+		}
+		key.Columns = append(key.Columns, col)
+		return nil
+	})
+	return keys, err
+}
+
+// Indexes returns the indexes defined on the given table. If uniqueOnly is
+// true only unique indexes are returned.
+func (conn *Connection) Indexes(catalog, schema, table string, uniqueOnly bool) ([]*Index, error) {
+	var (
+		cat                    = catalogArg(catalog)
+		sch                    = catalogArg(schema)
+		tab                    = catalogArg(table)
+		unique  C.SQLUSMALLINT = C.SQL_INDEX_ALL
+		indexes []*Index
+	)
+
+	if uniqueOnly {
+		unique = C.SQL_INDEX_UNIQUE
+	}
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLStatisticsW(h, cat.w, cat.n, sch.w, sch.n, tab.w, tab.n, unique, C.SQL_QUICK)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLStatistics(h, cat.a, cat.n, sch.a, sch.n, tab.a, tab.n, unique, C.SQL_QUICK)
+	}, func(stmt *Statement) error {
+		var (
+			idx       Index
+			col       IndexColumn
+			nonUnique bool
+			ordinal   int
+			order     string
+		)
+		if err := stmt.scan(
+			&idx.Catalog, &idx.Schema, &idx.Table, &nonUnique,
+			&idx.Qualifier, &idx.Name, &idx.Type, &ordinal,
+			&col.Name, &order, &idx.Cardinality, &idx.Pages,
+			&idx.Filter); err != nil {
+			return err
+		}
+		if idx.Type == C.SQL_TABLE_STAT {
+			return nil
+		}
+		idx.Unique = !nonUnique
+		col.Descending = order == "D"
+		if n := len(indexes); n == 0 || ordinal <= 1 || indexes[n-1].Name != idx.Name {
+			indexes = append(indexes, &idx)
+		}
+		last := indexes[len(indexes)-1]
+		last.Columns = append(last.Columns, col)
+		return nil
+	})
+	return indexes, err
+}
+
+// BestRowIdentifier returns the optimal set of columns that uniquely
+// identifies a row of the given table for the duration of the session.
+func (conn *Connection) BestRowIdentifier(catalog, schema, table string) ([]*SpecialColumn, error) {
+	return conn.specialColumns(C.SQL_BEST_ROWID, catalog, schema, table, C.SQL_SCOPE_SESSION)
+}
+
+// RowVersionColumns returns the columns of the given table that are
+// automatically updated when any value in the row is updated.
+func (conn *Connection) RowVersionColumns(catalog, schema, table string) ([]*SpecialColumn, error) {
+	return conn.specialColumns(C.SQL_ROWVER, catalog, schema, table, C.SQL_SCOPE_CURROW)
+}
+
+func (conn *Connection) specialColumns(idType C.SQLUSMALLINT, catalog, schema, table string, scope C.SQLUSMALLINT) ([]*SpecialColumn, error) {
+	var (
+		cat     = catalogArg(catalog)
+		sch     = catalogArg(schema)
+		tab     = catalogArg(table)
+		columns []*SpecialColumn
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLSpecialColumnsW(h, idType, cat.w, cat.n, sch.w, sch.n, tab.w, tab.n, scope, C.SQL_NULLABLE)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLSpecialColumns(h, idType, cat.a, cat.n, sch.a, sch.n, tab.a, tab.n, scope, C.SQL_NULLABLE)
+	}, func(stmt *Statement) error {
+		c := &SpecialColumn{}
+		if err := stmt.scan(
+			&c.Scope, &c.Name, &c.DataType, &c.TypeName,
+			&c.Size, &c.BufferLength, &c.DecimalDigits, &c.PseudoColumn); err != nil {
+			return err
+		}
+		columns = append(columns, c)
+		return nil
+	})
+	return columns, err
+}
+
+// ProcedureType tells whether a procedure returns a value.
+type ProcedureType int
+
+// Procedure types, as returned in Procedure.Type.
+const (
+	ProcedureUnknown  ProcedureType = C.SQL_PT_UNKNOWN
+	ProcedureNoReturn ProcedureType = C.SQL_PT_PROCEDURE
+	ProcedureFunction ProcedureType = C.SQL_PT_FUNCTION
+)
+
+// Procedure describes a stored procedure returned by SQLProcedures.
+type Procedure struct {
+	Catalog string
+	Schema  string
+	Name    string
+	Remarks string
+	Type    ProcedureType
+}
+
+// ParamDirection is the role of a procedure column.
+type ParamDirection int
+
+// Procedure column roles, as returned in ProcedureColumn.Direction.
+const (
+	ParamUnknown      ParamDirection = C.SQL_PARAM_TYPE_UNKNOWN
+	ParamInput        ParamDirection = C.SQL_PARAM_INPUT
+	ParamInputOutput  ParamDirection = C.SQL_PARAM_INPUT_OUTPUT
+	ParamResultColumn ParamDirection = C.SQL_RESULT_COL
+	ParamOutput       ParamDirection = C.SQL_PARAM_OUTPUT
+	ParamReturnValue  ParamDirection = C.SQL_RETURN_VALUE
+)
+
+func (d ParamDirection) String() string {
+	switch d {
+	case ParamInput:
+		return "IN"
+	case ParamInputOutput:
+		return "INOUT"
+	case ParamResultColumn:
+		return "RESULT"
+	case ParamOutput:
+		return "OUT"
+	case ParamReturnValue:
+		return "RETURN"
+	}
+	return "UNKNOWN"
+}
+
+// IsOutput reports whether a value is returned through the parameter, i.e.
+// whether it must be passed as a sql.Out.
+func (d ParamDirection) IsOutput() bool {
+	return d == ParamInputOutput || d == ParamOutput || d == ParamReturnValue
+}
+
+// ProcedureColumn describes a parameter, return value or result set column
+// of a stored procedure.
+type ProcedureColumn struct {
+	Catalog         string
+	Schema          string
+	Procedure       string
+	Name            string
+	Direction       ParamDirection
+	DataType        int
+	TypeName        string
+	Size            int
+	BufferLength    int
+	DecimalDigits   int
+	Radix           int
+	Nullable        int
+	Remarks         string
+	Default         sql.NullString
+	SQLDataType     int
+	DatetimeSubtype int
+	CharOctetLength int
+	Ordinal         int // 0 for the return value, 1-based otherwise.
+	IsNullable      string
+}
+
+// Procedures lists the stored procedures matching the given catalog,
+// schema and name. schema and name are search patterns unless
+// SetMetadataID(true) was called.
+func (conn *Connection) Procedures(catalog, schema, name string) ([]*Procedure, error) {
+	var (
+		cat        = catalogArg(catalog)
+		sch        = catalogArg(schema)
+		proc       = catalogArg(name)
+		procedures []*Procedure
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLProceduresW(h, cat.w, cat.n, sch.w, sch.n, proc.w, proc.n)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLProcedures(h, cat.a, cat.n, sch.a, sch.n, proc.a, proc.n)
+	}, func(stmt *Statement) error {
+		var (
+			p   = &Procedure{}
+			typ int
+		)
+		// Columns 4 to 6 are reserved for future use.
+		if err := stmt.scan(&p.Catalog, &p.Schema, &p.Name, nil, nil, nil, &p.Remarks, &typ); err != nil {
+			return err
+		}
+		p.Type = ProcedureType(typ)
+		procedures = append(procedures, p)
+		return nil
+	})
+	return procedures, err
+}
+
+// ProcedureColumns lists the parameters and result columns of the stored
+// procedures matching catalog, schema and procedure. schema, procedure and
+// column are search patterns unless SetMetadataID(true) was called.
+func (conn *Connection) ProcedureColumns(catalog, schema, procedure, column string) ([]*ProcedureColumn, error) {
+	var (
+		cat     = catalogArg(catalog)
+		sch     = catalogArg(schema)
+		proc    = catalogArg(procedure)
+		col     = catalogArg(column)
+		columns []*ProcedureColumn
+	)
+
+	err := conn.catalog(func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLProcedureColumnsW(h, cat.w, cat.n, sch.w, sch.n, proc.w, proc.n, col.w, col.n)
+	}, func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLProcedureColumns(h, cat.a, cat.n, sch.a, sch.n, proc.a, proc.n, col.a, col.n)
+	}, func(stmt *Statement) error {
+		var (
+			c   = &ProcedureColumn{}
+			dir int
+		)
+		if err := stmt.scan(
+			&c.Catalog, &c.Schema, &c.Procedure, &c.Name,
+			&dir, &c.DataType, &c.TypeName, &c.Size,
+			&c.BufferLength, &c.DecimalDigits, &c.Radix, &c.Nullable,
+			&c.Remarks, &c.Default, &c.SQLDataType, &c.DatetimeSubtype,
+			&c.CharOctetLength, &c.Ordinal, &c.IsNullable); err != nil {
+			return err
+		}
+		c.Direction = ParamDirection(dir)
+		columns = append(columns, c)
+		return nil
+	})
+	return columns, err
+}
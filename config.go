@@ -0,0 +1,94 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// config holds the package options of a connection string, see
+// OptionPrefix. Supported options:
+//
+//	godbc.login_timeout       SQL_ATTR_LOGIN_TIMEOUT, e.g. 30s
+//	godbc.connection_timeout  SQL_ATTR_CONNECTION_TIMEOUT, e.g. 30s
+//	godbc.query_timeout       default SQL_ATTR_QUERY_TIMEOUT of statements
+//	godbc.max_rows            default SQL_ATTR_MAX_ROWS of statements
+//	godbc.max_length          default SQL_ATTR_MAX_LENGTH of statements
+//	godbc.noscan              default SQL_ATTR_NOSCAN of statements, a bool
+//	godbc.async               run statements asynchronously, a bool
+//	godbc.log_info            log informational diagnostics, a bool
+//	godbc.stmt_cache_size     prepared statements kept by PrepareCached
+//
+// Durations without a unit are seconds.
+type config struct {
+	loginTimeout      time.Duration
+	connectionTimeout time.Duration
+
+	queryTimeout time.Duration
+	maxRows      int
+	maxLength    int
+	noScan       bool
+	async        bool
+
+	logInfo       bool
+	stmtCacheSize int
+}
+
+func parseConfig(opts map[string]string) (*config, error) {
+	var (
+		cfg = &config{}
+		err error
+	)
+
+	for name, value := range opts {
+		switch name {
+		case "login_timeout":
+			cfg.loginTimeout, err = parseDuration(value)
+		case "connection_timeout":
+			cfg.connectionTimeout, err = parseDuration(value)
+		case "query_timeout":
+			cfg.queryTimeout, err = parseDuration(value)
+		case "max_rows":
+			cfg.maxRows, err = strconv.Atoi(value)
+		case "max_length":
+			cfg.maxLength, err = strconv.Atoi(value)
+		case "noscan":
+			cfg.noScan, err = strconv.ParseBool(value)
+		case "async":
+			cfg.async, err = strconv.ParseBool(value)
+		case "log_info":
+			cfg.logInfo, err = strconv.ParseBool(value)
+		case "stmt_cache_size":
+			cfg.stmtCacheSize, err = strconv.Atoi(value)
+		default:
+			return nil, fmt.Errorf("godbc: unknown option %s%s", OptionPrefix, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("godbc: invalid option %s%s: %v", OptionPrefix, name, err)
+		}
+	}
+	return cfg, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+
+	if n, err := strconv.Atoi(s); err == nil {
+		d = time.Duration(n) * time.Second
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %s", s)
+	}
+	return d, nil
+}
+
+// seconds rounds d up to whole seconds, as ODBC timeouts are.
+func seconds(d time.Duration) uintptr {
+	return uintptr((d + time.Second - 1) / time.Second)
+}
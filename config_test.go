@@ -0,0 +1,39 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := parseConfig(map[string]string{
+		"login_timeout":      "5",
+		"connection_timeout": "1m30s",
+		"query_timeout":      "0",
+		"max_rows":           "10",
+		"async":              "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.loginTimeout != 5*time.Second || cfg.connectionTimeout != 90*time.Second ||
+		cfg.queryTimeout != 0 || cfg.maxRows != 10 || !cfg.async {
+		t.Errorf("got %+v", cfg)
+	}
+
+	for _, opts := range []map[string]string{
+		{"login_timeout": "-5"},
+		{"connection_timeout": "-1s"},
+		{"query_timeout": "soon"},
+		{"max_rows": "many"},
+		{"unknown": "1"},
+	} {
+		if cfg, err := parseConfig(opts); err == nil {
+			t.Errorf("parseConfig(%v) = %+v, want an error", opts, cfg)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"errors"
+	"strings"
+)
+
+// OptionPrefix marks the connection string keywords that configure this
+// package rather than the driver, e.g. "godbc.login_timeout=5s". They are
+// stripped before the connection string is handed to SQLDriverConnect.
+const OptionPrefix = "godbc."
+
+// ConnString is an ODBC connection string: an ordered list of
+// keyword=value pairs separated by semicolons, where values containing
+// special characters are enclosed in braces. Keywords are case-insensitive.
+type ConnString struct {
+	pairs []connPair
+}
+
+type connPair struct {
+	key, value string
+}
+
+// ParseConnString parses an ODBC connection string. Braced values may
+// contain any character, a literal '}' being written "}}".
+func ParseConnString(s string) (*ConnString, error) {
+	cs := &ConnString{}
+
+	for len(s) > 0 {
+		i := strings.IndexAny(s, "=;")
+		if i < 0 || s[i] == ';' {
+			seg := s
+			if i >= 0 {
+				seg, s = s[:i], s[i+1:]
+			} else {
+				s = ""
+			}
+			if seg = strings.TrimSpace(seg); seg != "" {
+				return nil, errors.New("godbc: missing '=' after connection string keyword " + seg)
+			}
+			continue
+		}
+
+		key := strings.TrimSpace(s[:i])
+		if key == "" {
+			return nil, errors.New("godbc: empty connection string keyword")
+		}
+		s = strings.TrimLeft(s[i+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, "{") {
+			var (
+				b      strings.Builder
+				closed bool
+			)
+			s = s[1:]
+			for len(s) > 0 {
+				j := strings.IndexByte(s, '}')
+				if j < 0 {
+					break
+				}
+				b.WriteString(s[:j])
+				if strings.HasPrefix(s[j:], "}}") {
+					b.WriteByte('}')
+					s = s[j+2:]
+					continue
+				}
+				s, closed = s[j+1:], true
+				break
+			}
+			if !closed {
+				return nil, errors.New("godbc: unterminated '{' in connection string value of " + key)
+			}
+			s = strings.TrimLeft(s, " \t")
+			if s != "" && s[0] != ';' {
+				return nil, errors.New("godbc: unexpected characters after '}' in connection string value of " + key)
+			}
+			value = b.String()
+		} else {
+			j := strings.IndexByte(s, ';')
+			if j < 0 {
+				j = len(s)
+			}
+			value = strings.TrimSpace(s[:j])
+			s = s[j:]
+		}
+		s = strings.TrimPrefix(s, ";")
+		cs.pairs = append(cs.pairs, connPair{key: key, value: value})
+	}
+	return cs, nil
+}
+
+// Get returns the value of the first occurrence of key.
+func (cs *ConnString) Get(key string) (string, bool) {
+	if i := cs.index(key); i >= 0 {
+		return cs.pairs[i].value, true
+	}
+	return "", false
+}
+
+// Set sets key to value. An existing key keeps its position and its
+// duplicates are dropped, a new one is appended.
+func (cs *ConnString) Set(key, value string) {
+	key = strings.TrimSpace(key)
+	i := cs.index(key)
+	if i < 0 {
+		cs.pairs = append(cs.pairs, connPair{key: key, value: value})
+		return
+	}
+	cs.pairs[i].value = value
+	cs.del(key, i+1)
+}
+
+// Del removes every occurrence of key.
+func (cs *ConnString) Del(key string) {
+	cs.del(key, 0)
+}
+
+// Keys returns the keywords in order, as they were written.
+func (cs *ConnString) Keys() []string {
+	keys := make([]string, len(cs.pairs))
+	for i, p := range cs.pairs {
+		keys[i] = p.key
+	}
+	return keys
+}
+
+// Options returns the package options, i.e. the values of the keywords
+// starting with OptionPrefix, keyed by their lower-cased name without the
+// prefix.
+func (cs *ConnString) Options() map[string]string {
+	opts := map[string]string{}
+	for _, p := range cs.pairs {
+		if isOption(p.key) {
+			name := strings.ToLower(p.key[len(OptionPrefix):])
+			if _, ok := opts[name]; !ok {
+				opts[name] = p.value
+			}
+		}
+	}
+	return opts
+}
+
+// String returns the connection string, package options included.
+func (cs *ConnString) String() string {
+	return cs.format(true)
+}
+
+// DriverString returns the connection string to hand to the driver, that
+// is without the package options.
+func (cs *ConnString) DriverString() string {
+	return cs.format(false)
+}
+
+func (cs *ConnString) format(options bool) string {
+	var b strings.Builder
+
+	for _, p := range cs.pairs {
+		if !options && isOption(p.key) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(p.key)
+		b.WriteByte('=')
+		b.WriteString(quoteConnValue(p.value))
+	}
+	return b.String()
+}
+
+func (cs *ConnString) index(key string) int {
+	key = strings.TrimSpace(key)
+	for i, p := range cs.pairs {
+		if strings.EqualFold(p.key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// del removes the occurrences of key found from index from on.
+func (cs *ConnString) del(key string, from int) {
+	key = strings.TrimSpace(key)
+	pairs := cs.pairs[:from]
+	for _, p := range cs.pairs[from:] {
+		if !strings.EqualFold(p.key, key) {
+			pairs = append(pairs, p)
+		}
+	}
+	cs.pairs = pairs
+}
+
+func isOption(key string) bool {
+	return len(key) >= len(OptionPrefix) && strings.EqualFold(key[:len(OptionPrefix)], OptionPrefix)
+}
+
+// quoteConnValue braces v when it could not be read back as is.
+func quoteConnValue(v string) string {
+	if !strings.ContainsAny(v, ";{}") && strings.TrimSpace(v) == v {
+		return v
+	}
+	return "{" + strings.ReplaceAll(v, "}", "}}") + "}"
+}
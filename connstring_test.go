@@ -0,0 +1,64 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConnString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []connPair
+	}{
+		{"", nil},
+		{"DSN=test", []connPair{{"DSN", "test"}}},
+		{" DSN = test ; UID=sa;;", []connPair{{"DSN", "test"}, {"UID", "sa"}}},
+		{"PWD={a;b}}c};Driver={SQL Server}", []connPair{{"PWD", "a;b}c"}, {"Driver", "SQL Server"}}},
+		{"PWD= { x } ;godbc.async=true", []connPair{{"PWD", " x "}, {"godbc.async", "true"}}},
+	}
+	for _, tt := range tests {
+		cs, err := ParseConnString(tt.in)
+		if err != nil {
+			t.Errorf("ParseConnString(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(cs.pairs, tt.want) {
+			t.Errorf("ParseConnString(%q) = %q, want %q", tt.in, cs.pairs, tt.want)
+		}
+	}
+
+	for _, in := range []string{"DSN", "=x", "PWD={abc", "PWD={a}b"} {
+		if _, err := ParseConnString(in); err == nil {
+			t.Errorf("ParseConnString(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func FuzzParseConnString(f *testing.F) {
+	for _, s := range []string{
+		"DSN=test;UID=sa;PWD=secret",
+		"Driver={SQL Server};Server=localhost;PWD={a;b}}c}",
+		"godbc.login_timeout=5s; godbc.async = true ;DSN= x ",
+		"PWD= {  } ;;",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		cs, err := ParseConnString(s)
+		if err != nil {
+			return
+		}
+		out := cs.String()
+		cs2, err := ParseConnString(out)
+		if err != nil {
+			t.Fatalf("ParseConnString(%q) of %q: %v", out, s, err)
+		}
+		if !reflect.DeepEqual(cs.pairs, cs2.pairs) {
+			t.Fatalf("round trip of %q through %q: got %q, want %q", s, out, cs2.pairs, cs.pairs)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"unsafe"
+)
+
+// Diagnostic is a diagnostic record posted by the driver, such as a
+// warning or a server message (e.g. SQL Server PRINT output).
+type Diagnostic struct {
+	SQLState    string
+	NativeError int
+	Message     string
+
+	ClassOrigin    string // "ISO 9075" or "ODBC 3.0", who defined the SQLSTATE class.
+	SubclassOrigin string // same for the subclass.
+	ServerName     string
+	ConnectionName string
+	RowNumber      int // row of the rowset or parameter set, or SQL_NO_ROW_NUMBER, SQL_ROW_NUMBER_UNKNOWN.
+	ColumnNumber   int // column or parameter, or SQL_NO_COLUMN_NUMBER, SQL_COLUMN_NUMBER_UNKNOWN.
+}
+
+// OnInfo registers f to receive the diagnostics of the connection's calls
+// that succeed with information (SQL_SUCCESS_WITH_INFO), including those
+// of its statements. A nil f removes the handler. To see the messages of
+// the connection itself, pass f to Connect instead.
+func (conn *Connection) OnInfo(f func(*Diagnostic)) {
+	conn.onInfo = f
+}
+
+// Warnings returns the diagnostics of the last Execute, Fetch or
+// NextResult call if it succeeded with information. Unless a handler is
+// registered with OnInfo, they are read on the first call to Warnings:
+// call it before reading the columns of a fetched row, which clears them.
+func (stmt *Statement) Warnings() []*Diagnostic {
+	if !stmt.withInfo {
+		return nil
+	}
+	if stmt.warnings == nil {
+		stmt.warnings = diagnostics(C.SQL_HANDLE_STMT, stmt.handle)
+	}
+	return stmt.warnings
+}
+
+// checkInfo records whether the statement call that returned ret posted
+// information, and hands it to the connection's handlers. It costs nothing
+// when there are none, Warnings then reads the diagnostics.
+func (stmt *Statement) checkInfo(ret C.SQLRETURN) {
+	stmt.withInfo = ret == C.SQL_SUCCESS_WITH_INFO
+	stmt.warnings = nil
+	if stmt.withInfo && stmt.conn.wantsInfo() {
+		stmt.warnings = diagnostics(C.SQL_HANDLE_STMT, stmt.handle)
+		stmt.conn.notify(stmt.warnings)
+	}
+}
+
+// checkInfo hands the diagnostics of the call on h that returned ret to
+// the connection's handlers. Nothing is read when there are none.
+func (conn *Connection) checkInfo(ret C.SQLRETURN, ht C.SQLSMALLINT, h C.SQLHANDLE) {
+	if ret == C.SQL_SUCCESS_WITH_INFO && conn.wantsInfo() {
+		conn.notify(diagnostics(ht, h))
+	}
+}
+
+func (conn *Connection) wantsInfo() bool {
+	return conn.onInfo != nil || conn.cfg != nil && conn.cfg.logInfo
+}
+
+func (conn *Connection) notify(diags []*Diagnostic) {
+	for _, d := range diags {
+		if conn.cfg != nil && conn.cfg.logInfo {
+			Logger().Info("godbc: "+d.Message, "sqlstate", d.SQLState, "native", d.NativeError)
+		}
+		if conn.onInfo != nil {
+			conn.onInfo(d)
+		}
+	}
+}
+
+// diagnostics reads the diagnostic records of h.
+func diagnostics(ht C.SQLSMALLINT, h C.SQLHANDLE) []*Diagnostic {
+	var (
+		diags       []*Diagnostic
+		nativeError C.SQLINTEGER
+		textLength  C.SQLSMALLINT
+		sqlState    = make([]uint16, 6)
+		messageText = make([]uint16, C.SQL_MAX_MESSAGE_LENGTH)
+	)
+
+	for i := 1; ; i++ {
+		if ret := C.SQLGetDiagRecW(
+			ht,
+			h,
+			C.SQLSMALLINT(i),
+			(*C.SQLWCHAR)(unsafe.Pointer(&sqlState[0])),
+			&nativeError,
+			(*C.SQLWCHAR)(unsafe.Pointer(&messageText[0])),
+			C.SQLSMALLINT(len(messageText)),
+			&textLength); !Success(ret) {
+			break
+		}
+		if int(textLength) >= len(messageText) {
+			// Truncated: read the record again with room for all of it.
+			messageText = make([]uint16, int(textLength)+1)
+			i--
+			continue
+		}
+
+		d := &Diagnostic{
+			SQLState:       UTF16ToString(sqlState),
+			NativeError:    int(nativeError),
+			Message:        UTF16ToString(messageText[:textLength]),
+			ClassOrigin:    diagString(ht, h, i, C.SQL_DIAG_CLASS_ORIGIN),
+			SubclassOrigin: diagString(ht, h, i, C.SQL_DIAG_SUBCLASS_ORIGIN),
+			ServerName:     diagString(ht, h, i, C.SQL_DIAG_SERVER_NAME),
+			ConnectionName: diagString(ht, h, i, C.SQL_DIAG_CONNECTION_NAME),
+		}
+		// Row and column numbers only exist for statements.
+		if ht == C.SQL_HANDLE_STMT {
+			var (
+				row C.SQLLEN
+				col C.SQLINTEGER
+			)
+			diagField(ht, h, i, C.SQL_DIAG_ROW_NUMBER, unsafe.Pointer(&row), C.SQL_IS_INTEGER)
+			diagField(ht, h, i, C.SQL_DIAG_COLUMN_NUMBER, unsafe.Pointer(&col), C.SQL_IS_INTEGER)
+			d.RowNumber, d.ColumnNumber = int(row), int(col)
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// diagState returns the SQLSTATE of the first diagnostic record of h, or
+// "" when there is none.
+func diagState(ht C.SQLSMALLINT, h C.SQLHANDLE) string {
+	return diagString(ht, h, 1, C.SQL_DIAG_SQLSTATE)
+}
+
+// diagString reads the string diagnostic field of record rec, 0 being the
+// header. It is empty when the driver does not report it.
+func diagString(ht C.SQLSMALLINT, h C.SQLHANDLE, rec int, field C.SQLSMALLINT) string {
+	var (
+		n   C.SQLSMALLINT
+		buf = make([]uint16, 64)
+	)
+
+	for {
+		if ret := C.SQLGetDiagFieldW(
+			ht,
+			h,
+			C.SQLSMALLINT(rec),
+			field,
+			C.SQLPOINTER(unsafe.Pointer(&buf[0])),
+			C.SQLSMALLINT(len(buf)*2),
+			&n); !Success(ret) {
+			return ""
+		}
+		if int(n)/2 < len(buf) {
+			return UTF16ToString(buf[:n/2])
+		}
+		buf = make([]uint16, int(n)/2+1)
+	}
+}
+
+// diagField reads the fixed-size diagnostic field of record rec into ptr.
+func diagField(ht C.SQLSMALLINT, h C.SQLHANDLE, rec int, field C.SQLSMALLINT, ptr unsafe.Pointer, size C.SQLSMALLINT) bool {
+	return Success(C.SQLGetDiagFieldW(ht, h, C.SQLSMALLINT(rec), field, C.SQLPOINTER(ptr), size, nil))
+}
@@ -5,10 +5,12 @@
 package driver
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"io"
+	"strings"
 
 	"github.com/creack/godbc"
 )
@@ -33,13 +35,52 @@ func (d *Driver) Open(dsn string) (driver.Conn, error) {
 // Close terminates the session
 func (d *Driver) Close() error { return nil }
 
+// OpenConnector implements driver.DriverContext.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	return &Connector{dsn: dsn, driver: d}, nil
+}
+
+// Connector opens connections with a fixed connection string. Use it with
+// sql.OpenDB to get context aware connection establishment:
+//
+//	db := sql.OpenDB(driver.NewConnector("DSN=test;godbc.connection_timeout=30"))
+type Connector struct {
+	dsn    string
+	attrs  []interface{}
+	driver *Driver
+}
+
+// NewConnector returns a Connector for the connection string dsn, which may
+// hold package options, see godbc.OptionPrefix. attrs are set on each new
+// connection before it is established.
+func NewConnector(dsn string, attrs ...godbc.Attr) *Connector {
+	c := &Connector{dsn: dsn, driver: &Driver{}}
+	for _, a := range attrs {
+		c.attrs = append(c.attrs, a)
+	}
+	return c
+}
+
+// Connect implements driver.Connector. The context deadline bounds the
+// login time and cancelling the context abandons the attempt.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	gc, err := godbc.ConnectContext(ctx, c.dsn, c.attrs...)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c: gc}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *Connector) Driver() driver.Driver { return c.driver }
+
 type conn struct {
 	c *godbc.Connection
 	t *tx
 }
 
 func (c *conn) Prepare(query string) (driver.Stmt, error) {
-	st, err := c.c.Prepare(query)
+	st, err := c.c.PrepareCached(query)
 	if err != nil {
 		return nil, err
 	}
@@ -47,10 +88,50 @@ func (c *conn) Prepare(query string) (driver.Stmt, error) {
 }
 
 func (c *conn) Begin() (driver.Tx, error) {
-	if err := c.c.AutoCommit(false); err != nil {
+	t, err := c.c.BeginTx(0, false)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{t: t}, nil
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	isolation, err := godbc.TxnIsolationOf(sql.IsolationLevel(opts.Isolation))
+	if err != nil {
 		return nil, err
 	}
-	return &tx{c: c}, nil
+	t, err := c.c.BeginTx(isolation, opts.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{t: t}, nil
+}
+
+// Connection returns the godbc connection behind driverConn, the value
+// passed to the sql.Conn.Raw callback:
+//
+//	err := c.Raw(func(dc interface{}) error {
+//		tables, err := driver.Connection(dc).Tables("", "", "%", "TABLE")
+//		...
+//	})
+//
+// It returns nil if driverConn was not created by this driver.
+func Connection(driverConn interface{}) *godbc.Connection {
+	if c, ok := driverConn.(*conn); ok {
+		return c.c
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter, discarding connections
+// known to be lost. The statement cache is kept: it is what makes the
+// statements of a pooled connection cheap to prepare again.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if c.c.Lost() {
+		return driver.ErrBadConn
+	}
+	return nil
 }
 
 func (c *conn) Close() error {
@@ -61,19 +142,20 @@ func (c *conn) Close() error {
 }
 
 type tx struct {
-	c *conn
+	t *godbc.Tx
 }
 
 func (t *tx) Commit() error {
-	return t.c.c.Commit()
+	return t.t.Commit()
 }
 
 func (t *tx) Rollback() error {
-	return t.c.c.Rollback()
+	return t.t.Rollback()
 }
 
 type stmt struct {
-	st *godbc.Statement
+	st     *godbc.Statement
+	closed bool
 }
 
 func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
@@ -84,6 +166,15 @@ func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
 	return &result{rowsAffected: int64(rowsAffected)}, err
 }
 
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.st.ExecuteContext(ctx, values(args)...); err != nil {
+		return nil, err
+	}
+	rowsAffected, err := s.st.RowsAffected()
+	return &result{rowsAffected: int64(rowsAffected)}, err
+}
+
 func (s *stmt) NumInput() int {
 	return s.st.NumParams()
 }
@@ -92,11 +183,33 @@ func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
 	if err := s.st.Execute2(args); err != nil {
 		return nil, err
 	}
-	return &rows{s: s}, nil
+	return &rows{s: s, ctx: context.Background()}, nil
 }
 
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.st.ExecuteContext(ctx, values(args)...); err != nil {
+		return nil, err
+	}
+	return &rows{s: s, ctx: ctx}, nil
+}
+
+// values returns the positional values of args.
+func values(args []driver.NamedValue) []interface{} {
+	v := make([]interface{}, len(args))
+	for _, a := range args {
+		v[a.Ordinal-1] = a.Value
+	}
+	return v
+}
+
+// Close gives the statement back to the connection's statement cache, or
+// closes it when there is none.
 func (s *stmt) Close() error {
-	s.st.Close()
+	if !s.closed {
+		s.closed = true
+		s.st.Release()
+	}
 	return nil
 }
 
@@ -109,7 +222,8 @@ func (r *result) LastInsertId() (int64, error) { return 0, errors.New("not suppo
 func (r *result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
 
 type rows struct {
-	s *stmt
+	s   *stmt
+	ctx context.Context // of the query, fetches give up when it is done.
 }
 
 func (r *rows) Columns() []string {
@@ -128,12 +242,23 @@ func (r *rows) Columns() []string {
 	return columns
 }
 
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	name, err := r.s.st.DatabaseTypeName(index + 1)
+	if err != nil {
+		return ""
+	}
+	return strings.ToUpper(name)
+}
+
+// Close closes the cursor, leaving the statement ready to be executed
+// again. database/sql closes the statement itself when it is done with it.
 func (r *rows) Close() error {
-	return r.s.Close()
+	return r.s.st.CloseCursor()
 }
 
 func (r *rows) Next(dest []driver.Value) error {
-	eof, err := r.s.st.FetchOne2(dest)
+	eof, err := r.s.st.FetchOne2Context(r.ctx, dest)
 	if err != nil {
 		return err
 	}
@@ -142,3 +267,32 @@ func (r *rows) Next(dest []driver.Value) error {
 	}
 	return nil
 }
+
+// Savepoint sets a savepoint in the transaction running on c, see
+// godbc.Connection.Savepoint. Begin the transaction with c.BeginTx.
+func Savepoint(ctx context.Context, c *sql.Conn, name string) error {
+	return raw(ctx, c, func(gc *godbc.Connection) error { return gc.Savepoint(name) })
+}
+
+// RollbackTo rolls the transaction running on c back to a savepoint.
+func RollbackTo(ctx context.Context, c *sql.Conn, name string) error {
+	return raw(ctx, c, func(gc *godbc.Connection) error { return gc.RollbackTo(name) })
+}
+
+// ReleaseSavepoint removes a savepoint of the transaction running on c.
+func ReleaseSavepoint(ctx context.Context, c *sql.Conn, name string) error {
+	return raw(ctx, c, func(gc *godbc.Connection) error { return gc.ReleaseSavepoint(name) })
+}
+
+func raw(ctx context.Context, c *sql.Conn, f func(*godbc.Connection) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Raw(func(dc interface{}) error {
+		gc := Connection(dc)
+		if gc == nil {
+			return errors.New("godbc: not a connection of this driver")
+		}
+		return f(gc)
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2012, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// TestStmtCacheAcrossPool needs a data source, set GODBC_TEST_DSN to its
+// connection string.
+func TestStmtCacheAcrossPool(t *testing.T) {
+	dsn := os.Getenv("GODBC_TEST_DSN")
+	if dsn == "" {
+		t.Skip("GODBC_TEST_DSN not set")
+	}
+	db, err := sql.Open("odbc", dsn+";godbc.stmt_cache_size=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// A single connection, reused and reset by the pool for each query.
+	db.SetMaxOpenConns(1)
+
+	for i := 0; i < 2; i++ {
+		var n int
+		if err := db.QueryRow("SELECT 1").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	err = c.Raw(func(dc interface{}) error {
+		if st := Connection(dc).CacheStats(); st.Hits != 1 || st.Misses != 1 {
+			t.Errorf("got %d hits and %d misses, want 1 and 1", st.Hits, st.Misses)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
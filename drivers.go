@@ -0,0 +1,129 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// DriverInfo describes a driver installed in the driver manager.
+type DriverInfo struct {
+	Description string
+	Attributes  map[string]string // e.g. Driver, Setup, FileUsage.
+}
+
+// DataSource is a data source name known to the driver manager.
+type DataSource struct {
+	Name        string
+	Description string // description of the data source's driver.
+}
+
+// DataSourceScope selects which data sources DataSources lists.
+type DataSourceScope int
+
+// Data source scopes.
+const (
+	AllDataSources    DataSourceScope = C.SQL_FETCH_FIRST
+	UserDataSources   DataSourceScope = C.SQL_FETCH_FIRST_USER
+	SystemDataSources DataSourceScope = C.SQL_FETCH_FIRST_SYSTEM
+)
+
+// Drivers lists the drivers installed in the driver manager. With
+// unixODBC they come from the odbcinst.ini file found in ODBCSYSINI.
+func Drivers() ([]*DriverInfo, error) {
+	var drivers []*DriverInfo
+
+	err := enumerate(C.SQL_FETCH_FIRST, func(dir C.SQLUSMALLINT, desc, attrs []byte, descLen, attrsLen *C.SQLSMALLINT) C.SQLRETURN {
+		return C.SQLDrivers(C.SQLHENV(Genv), dir,
+			(*C.SQLCHAR)(unsafe.Pointer(&desc[0])), C.SQLSMALLINT(len(desc)), descLen,
+			(*C.SQLCHAR)(unsafe.Pointer(&attrs[0])), C.SQLSMALLINT(len(attrs)), attrsLen)
+	}, func(desc, attrs string) {
+		d := &DriverInfo{Description: desc, Attributes: map[string]string{}}
+		// Attributes come as "key=value\0key=value\0".
+		for _, kv := range strings.Split(attrs, "\x00") {
+			if kv == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(kv, "=")
+			d.Attributes[k] = v
+		}
+		drivers = append(drivers, d)
+	})
+	return drivers, err
+}
+
+// DataSources lists the data sources of the given scope. With unixODBC
+// user data sources come from ODBCINI (~/.odbc.ini by default) and system
+// ones from the odbc.ini file found in ODBCSYSINI.
+func DataSources(scope DataSourceScope) ([]*DataSource, error) {
+	var sources []*DataSource
+
+	err := enumerate(C.SQLUSMALLINT(scope), func(dir C.SQLUSMALLINT, name, desc []byte, nameLen, descLen *C.SQLSMALLINT) C.SQLRETURN {
+		return C.SQLDataSources(C.SQLHENV(Genv), dir,
+			(*C.SQLCHAR)(unsafe.Pointer(&name[0])), C.SQLSMALLINT(len(name)), nameLen,
+			(*C.SQLCHAR)(unsafe.Pointer(&desc[0])), C.SQLSMALLINT(len(desc)), descLen)
+	}, func(name, desc string) {
+		sources = append(sources, &DataSource{Name: name, Description: desc})
+	})
+	return sources, err
+}
+
+// enumerate drives SQLDrivers and SQLDataSources, which return two strings
+// per entry. As an entry cannot be fetched again, the enumeration restarts
+// from first with larger buffers when one of them was truncated.
+func enumerate(first C.SQLUSMALLINT, call func(C.SQLUSMALLINT, []byte, []byte, *C.SQLSMALLINT, *C.SQLSMALLINT) C.SQLRETURN, entry func(string, string)) error {
+	var (
+		a, b       = make([]byte, infoBufferLen), make([]byte, bufferSize)
+		aLen, bLen C.SQLSMALLINT
+		values     [][2]string
+		dir        = first
+	)
+
+	for {
+		ret := call(dir, a, b, &aLen, &bLen)
+		if ret == C.SQL_NO_DATA {
+			break
+		}
+		if !Success(ret) {
+			return FormatError(C.SQL_HANDLE_ENV, Genv)
+		}
+		// Buffers grow up to what a SQLSMALLINT holds, longer values are
+		// kept truncated.
+		if int(aLen) >= len(a) && len(a) < math.MaxInt16 || int(bLen) >= len(b) && len(b) < math.MaxInt16 {
+			if int(aLen) >= len(a) {
+				a = make([]byte, min(int(aLen)+1, math.MaxInt16))
+			}
+			if int(bLen) >= len(b) {
+				b = make([]byte, min(int(bLen)+1, math.MaxInt16))
+			}
+			values = values[:0]
+			dir = first
+			continue
+		}
+		aLen, bLen = min(aLen, C.SQLSMALLINT(len(a)-1)), min(bLen, C.SQLSMALLINT(len(b)-1))
+		values = append(values, [2]string{string(a[:aLen]), string(b[:bLen])})
+		dir = C.SQL_FETCH_NEXT
+	}
+	for _, v := range values {
+		entry(v[0], v[1])
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testOdbcinstIni = `[Test Driver]
+Description=Driver for tests
+Driver=/nonexistent/libtestdriver.so
+FileUsage=1
+`
+	testOdbcIni = `[testdsn]
+Driver=Test Driver
+Description=Data source for tests
+`
+)
+
+// TestDriversAndDataSources reads a unixODBC configuration written to a
+// temporary directory. The driver manager may read and remember the
+// configuration paths as soon as the environment handle is allocated, so
+// the test runs again in a child process started with ODBCSYSINI and
+// ODBCINI pointing there.
+func TestDriversAndDataSources(t *testing.T) {
+	if os.Getenv("GODBC_TEST_INI") == "" {
+		if _, err := exec.LookPath("odbcinst"); err != nil {
+			t.Skip("unixODBC not available")
+		}
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"odbcinst.ini": testOdbcinstIni,
+			"odbc.ini":     testOdbcIni,
+			"user.ini":     "",
+		} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDriversAndDataSources$")
+		cmd.Env = append(os.Environ(),
+			"GODBC_TEST_INI=1",
+			"ODBCSYSINI="+dir,
+			"ODBCINSTINI=odbcinst.ini",
+			"ODBCINI="+filepath.Join(dir, "user.ini"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		return
+	}
+
+	drivers, err := Drivers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found *DriverInfo
+	for _, d := range drivers {
+		if d.Description == "Test Driver" {
+			found = d
+		}
+	}
+	if found == nil {
+		t.Fatalf("Test Driver not in %d drivers", len(drivers))
+	}
+	for k, want := range map[string]string{
+		"Description": "Driver for tests",
+		"Driver":      "/nonexistent/libtestdriver.so",
+		"FileUsage":   "1",
+	} {
+		if got := found.Attributes[k]; got != want {
+			t.Errorf("driver attribute %s = %q, want %q", k, got, want)
+		}
+	}
+
+	sources, err := DataSources(SystemDataSources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Name != "testdsn" || sources[0].Description != "Test Driver" {
+		t.Errorf("system data sources = %+v, want testdsn of Test Driver", sources)
+	}
+	if sources, err := DataSources(UserDataSources); err != nil || len(sources) != 0 {
+		t.Errorf("user data sources = %+v, %v, want none", sources, err)
+	}
+}
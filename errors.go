@@ -0,0 +1,231 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"errors"
+	"strings"
+)
+
+// Error classes. An *Error matches them with errors.Is according to the
+// SQLSTATE of its diagnostic records and, where the SQLSTATE is too
+// generic, to the native error codes of the DBMS the connection talks to.
+var (
+	ErrIntegrityViolation   = errors.New("godbc: integrity constraint violation")
+	ErrUniqueViolation      = errors.New("godbc: unique constraint violation")
+	ErrForeignKeyViolation  = errors.New("godbc: foreign key constraint violation")
+	ErrDeadlock             = errors.New("godbc: deadlock")
+	ErrSerializationFailure = errors.New("godbc: serialization failure")
+	ErrTimeout              = errors.New("godbc: timeout")
+	ErrConnectionLost       = errors.New("godbc: connection lost")
+	ErrSyntax               = errors.New("godbc: syntax error or access rule violation")
+	ErrPermissionDenied     = errors.New("godbc: permission denied")
+	ErrDataTruncated        = errors.New("godbc: data truncated")
+)
+
+// dbmsKind identifies the DBMS behind a connection, to interpret native
+// error codes.
+type dbmsKind int
+
+const (
+	dbmsUnknown dbmsKind = iota
+	dbmsSQLServer
+	dbmsOracle
+	dbmsPostgreSQL
+	dbmsMySQL
+	dbmsDB2
+	dbmsSQLite
+)
+
+// detectDBMS maps an SQL_DBMS_NAME to its kind.
+func detectDBMS(name string) dbmsKind {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "sql server"):
+		return dbmsSQLServer
+	case strings.Contains(name, "oracle"):
+		return dbmsOracle
+	case strings.Contains(name, "postgres"):
+		return dbmsPostgreSQL
+	case strings.Contains(name, "mysql"), strings.Contains(name, "mariadb"):
+		return dbmsMySQL
+	case strings.Contains(name, "db2"):
+		return dbmsDB2
+	case strings.Contains(name, "sqlite"):
+		return dbmsSQLite
+	}
+	return dbmsUnknown
+}
+
+func (conn *Connection) detectDBMS() {
+	name, err := conn.getInfoString(InfoDBMSName)
+	if err != nil {
+		Logger().Debug("godbc: cannot read the DBMS name", "err", err)
+		return
+	}
+	conn.dbms = detectDBMS(name)
+}
+
+// errorRule tells which diagnostic records belong to an error class.
+type errorRule struct {
+	states  []string           // SQLSTATEs, or two-character classes.
+	natives map[dbmsKind][]int // native error codes per DBMS.
+	unless  error              // a more specific class that excludes this one.
+}
+
+var errorRules = map[error]errorRule{
+	ErrIntegrityViolation: {
+		states: []string{"23", "40002"},
+	},
+	ErrUniqueViolation: {
+		states: []string{"23505"},
+		natives: map[dbmsKind][]int{
+			dbmsSQLServer: {2601, 2627},
+			dbmsOracle:    {1},
+			dbmsMySQL:     {1062},
+			dbmsDB2:       {-803},
+			dbmsSQLite:    {1555, 2067},
+		},
+	},
+	ErrForeignKeyViolation: {
+		states: []string{"23503"},
+		natives: map[dbmsKind][]int{
+			dbmsSQLServer: {547},
+			dbmsOracle:    {2291, 2292},
+			dbmsMySQL:     {1451, 1452},
+			dbmsDB2:       {-530, -532},
+			dbmsSQLite:    {787},
+		},
+	},
+	ErrDeadlock: {
+		states: []string{"40P01"},
+		natives: map[dbmsKind][]int{
+			dbmsSQLServer: {1205},
+			dbmsOracle:    {60},
+			dbmsMySQL:     {1213},
+			dbmsDB2:       {-911},
+		},
+	},
+	// A deadlock victim is rolled back just like a serialization failure,
+	// and can be retried the same way. The rest of class 40 is not
+	// retryable: 40002 is a constraint violation on commit, 40003 leaves
+	// the outcome unknown.
+	ErrSerializationFailure: {
+		states: []string{"40001", "40P01"},
+		natives: map[dbmsKind][]int{
+			dbmsSQLServer: {1205, 3960},
+			dbmsOracle:    {60, 8177},
+			dbmsMySQL:     {1213},
+			dbmsDB2:       {-911, -913},
+		},
+	},
+	ErrTimeout: {
+		states: []string{"HYT00", "HYT01"},
+		natives: map[dbmsKind][]int{
+			dbmsSQLServer: {1222},
+			dbmsOracle:    {30006},
+			dbmsMySQL:     {1205},
+		},
+	},
+	ErrConnectionLost: {
+		states: []string{"08S01", "08003", "08007"},
+		natives: map[dbmsKind][]int{
+			dbmsOracle: {3113, 3114, 3135},
+			dbmsMySQL:  {2006, 2013},
+		},
+	},
+	ErrSyntax: {
+		states: []string{"42", "37000"},
+		unless: ErrPermissionDenied,
+	},
+	ErrPermissionDenied: {
+		states: []string{"28", "42501"},
+		natives: map[dbmsKind][]int{
+			dbmsSQLServer: {229, 230, 262, 300},
+			dbmsOracle:    {1031, 1017},
+			dbmsMySQL:     {1044, 1045, 1142, 1143},
+			dbmsDB2:       {-551, -552},
+		},
+	},
+	ErrDataTruncated: {
+		states: []string{"01004", "22001"},
+	},
+}
+
+// Is reports whether the error belongs to the error class target, one of
+// the Err* variables of this package.
+func (e *Error) Is(target error) bool {
+	r, ok := errorRules[target]
+	if !ok {
+		return false
+	}
+	if r.unless != nil && e.Is(r.unless) {
+		return false
+	}
+	for _, d := range e.records() {
+		if r.match(e.dbms, d.SQLState, d.NativeError) {
+			return true
+		}
+	}
+	return false
+}
+
+// records returns the diagnostic records of e, made up from its SQLSTATE
+// for an Error built by hand.
+func (e *Error) records() []*Diagnostic {
+	if len(e.Diagnostics) > 0 {
+		return e.Diagnostics
+	}
+	return []*Diagnostic{{SQLState: e.SQLState, NativeError: e.NativeError}}
+}
+
+func (r errorRule) match(dbms dbmsKind, state string, native int) bool {
+	for _, s := range r.states {
+		if strings.HasPrefix(state, s) {
+			return true
+		}
+	}
+	for _, n := range r.natives[dbms] {
+		if native == n {
+			return true
+		}
+	}
+	return false
+}
+
+// error returns the diagnostics of the connection as an *Error that knows
+// its DBMS.
+func (conn *Connection) error() error {
+	return conn.formatError(C.SQL_HANDLE_DBC, conn.Dbc)
+}
+
+// error returns the diagnostics of the statement as an *Error that knows
+// its DBMS.
+func (stmt *Statement) error() error {
+	return stmt.conn.formatError(C.SQL_HANDLE_STMT, stmt.handle)
+}
+
+func (conn *Connection) formatError(ht C.SQLSMALLINT, h C.SQLHANDLE) error {
+	err := FormatError(ht, h)
+	if e, ok := err.(*Error); ok && conn != nil {
+		e.dbms = conn.dbms
+		conn.checkError(err)
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import "unsafe"
+
+// ODBC function identifiers, for FunctionSet.Has.
+const (
+	APIBindParameter    = C.SQL_API_SQLBINDPARAMETER
+	APIBrowseConnect    = C.SQL_API_SQLBROWSECONNECT
+	APIBulkOperations   = C.SQL_API_SQLBULKOPERATIONS
+	APICancel           = C.SQL_API_SQLCANCEL
+	APICancelHandle     = C.SQL_API_SQLCANCELHANDLE
+	APICloseCursor      = C.SQL_API_SQLCLOSECURSOR
+	APIColumns          = C.SQL_API_SQLCOLUMNS
+	APIDescribeParam    = C.SQL_API_SQLDESCRIBEPARAM
+	APIFetchScroll      = C.SQL_API_SQLFETCHSCROLL
+	APIForeignKeys      = C.SQL_API_SQLFOREIGNKEYS
+	APIGetCursorName    = C.SQL_API_SQLGETCURSORNAME
+	APIGetTypeInfo      = C.SQL_API_SQLGETTYPEINFO
+	APIMoreResults      = C.SQL_API_SQLMORERESULTS
+	APINumParams        = C.SQL_API_SQLNUMPARAMS
+	APIPrepare          = C.SQL_API_SQLPREPARE
+	APIPrimaryKeys      = C.SQL_API_SQLPRIMARYKEYS
+	APIProcedureColumns = C.SQL_API_SQLPROCEDURECOLUMNS
+	APIProcedures       = C.SQL_API_SQLPROCEDURES
+	APIRowCount         = C.SQL_API_SQLROWCOUNT
+	APISetCursorName    = C.SQL_API_SQLSETCURSORNAME
+	APISetPos           = C.SQL_API_SQLSETPOS
+	APISpecialColumns   = C.SQL_API_SQLSPECIALCOLUMNS
+	APIStatistics       = C.SQL_API_SQLSTATISTICS
+	APITables           = C.SQL_API_SQLTABLES
+)
+
+// FunctionSet is the SQL_API_ODBC3_ALL_FUNCTIONS bitmap returned by
+// SQLGetFunctions.
+type FunctionSet [C.SQL_API_ODBC3_ALL_FUNCTIONS_SIZE]uint16
+
+// Has reports whether the driver implements the function identified by api,
+// one of the API* constants or any other SQL_API_* value.
+func (f *FunctionSet) Has(api int) bool {
+	if api < 0 || api>>4 >= len(f) {
+		return false
+	}
+	return f[api>>4]&(1<<uint(api&0xF)) != 0
+}
+
+// SupportedFunctions returns the set of ODBC functions the driver
+// implements. The result is cached after the first call.
+func (conn *Connection) SupportedFunctions() (*FunctionSet, error) {
+	if conn.functions != nil || conn.functionsErr != nil {
+		return conn.functions, conn.functionsErr
+	}
+
+	f := &FunctionSet{}
+	if ret := C.SQLGetFunctions(
+		C.SQLHDBC(conn.Dbc),
+		C.SQL_API_ODBC3_ALL_FUNCTIONS,
+		(*C.SQLUSMALLINT)(unsafe.Pointer(&f[0]))); !Success(ret) {
+		// Asking again would fail the same way.
+		conn.functionsErr = conn.error()
+		return nil, conn.functionsErr
+	}
+	conn.functions = f
+	return f, nil
+}
+
+// supports reports whether the driver implements api. If the driver
+// cannot tell, the function is assumed to be there.
+func (conn *Connection) supports(api int) bool {
+	f, err := conn.SupportedFunctions()
+	if err != nil {
+		return true
+	}
+	return f.Has(api)
+}
+
+// callW calls wide, the W (UTF-16) variant of a driver function, or ansi
+// once the driver turned out to lack the W functions: SQLSTATE IM001,
+// which is remembered for the connection. ht and h are the handle the
+// functions post their diagnostics on.
+func (conn *Connection) callW(ht C.SQLSMALLINT, h C.SQLHANDLE, wide, ansi func() C.SQLRETURN) C.SQLRETURN {
+	if !conn.ansi {
+		ret := wide()
+		if ret != C.SQL_ERROR || diagState(ht, h) != "IM001" {
+			return ret
+		}
+		Logger().Debug("godbc: driver lacks the W functions, using ANSI functions")
+		conn.ansi = true
+	}
+	return ansi()
+}
+
+// callW is conn.callW for a function of the statement, waiting for it to
+// complete when the statement is asynchronous.
+func (stmt *Statement) callW(wide, ansi func() C.SQLRETURN) C.SQLRETURN {
+	return stmt.wait(func() C.SQLRETURN {
+		return stmt.conn.callW(C.SQL_HANDLE_STMT, stmt.handle, wide, ansi)
+	})
+}
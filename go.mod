@@ -0,0 +1,3 @@
+module github.com/creack/godbc
+
+go 1.21
@@ -0,0 +1,331 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unsafe"
+)
+
+// Information types for GetInfo. Any other SQLGetInfo information type can
+// be queried with GetInfoKind.
+const (
+	InfoDataSourceName       = C.SQL_DATA_SOURCE_NAME
+	InfoServerName           = C.SQL_SERVER_NAME
+	InfoDatabaseName         = C.SQL_DATABASE_NAME
+	InfoUserName             = C.SQL_USER_NAME
+	InfoDBMSName             = C.SQL_DBMS_NAME
+	InfoDBMSVersion          = C.SQL_DBMS_VER
+	InfoDriverName           = C.SQL_DRIVER_NAME
+	InfoDriverVersion        = C.SQL_DRIVER_VER
+	InfoDriverODBCVersion    = C.SQL_DRIVER_ODBC_VER
+	InfoODBCVersion          = C.SQL_ODBC_VER
+	InfoIdentifierQuoteChar  = C.SQL_IDENTIFIER_QUOTE_CHAR
+	InfoSearchPatternEscape  = C.SQL_SEARCH_PATTERN_ESCAPE
+	InfoSpecialCharacters    = C.SQL_SPECIAL_CHARACTERS
+	InfoKeywords             = C.SQL_KEYWORDS
+	InfoCatalogTerm          = C.SQL_CATALOG_TERM
+	InfoCatalogNameSeparator = C.SQL_CATALOG_NAME_SEPARATOR
+	InfoSchemaTerm           = C.SQL_SCHEMA_TERM
+	InfoTableTerm            = C.SQL_TABLE_TERM
+	InfoProcedureTerm        = C.SQL_PROCEDURE_TERM
+	InfoMultResultSets       = C.SQL_MULT_RESULT_SETS
+	InfoDataSourceReadOnly   = C.SQL_DATA_SOURCE_READ_ONLY
+	InfoMaxIdentifierLen     = C.SQL_MAX_IDENTIFIER_LEN
+	InfoMaxColumnNameLen     = C.SQL_MAX_COLUMN_NAME_LEN
+	InfoMaxTableNameLen      = C.SQL_MAX_TABLE_NAME_LEN
+	InfoMaxSchemaNameLen     = C.SQL_MAX_SCHEMA_NAME_LEN
+	InfoMaxCatalogNameLen    = C.SQL_MAX_CATALOG_NAME_LEN
+	InfoMaxProcedureNameLen  = C.SQL_MAX_PROCEDURE_NAME_LEN
+	InfoMaxCursorNameLen     = C.SQL_MAX_CURSOR_NAME_LEN
+	InfoMaxConcurrentActs    = C.SQL_MAX_CONCURRENT_ACTIVITIES
+	InfoMaxDriverConnections = C.SQL_MAX_DRIVER_CONNECTIONS
+	InfoTxnCapable           = C.SQL_TXN_CAPABLE
+	InfoCursorCommitBehavior = C.SQL_CURSOR_COMMIT_BEHAVIOR
+	InfoIdentifierCase       = C.SQL_IDENTIFIER_CASE
+	InfoQuotedIdentifierCase = C.SQL_QUOTED_IDENTIFIER_CASE
+	InfoDefaultTxnIsolation  = C.SQL_DEFAULT_TXN_ISOLATION
+	InfoMaxStatementLen      = C.SQL_MAX_STATEMENT_LEN
+	InfoMaxRowSize           = C.SQL_MAX_ROW_SIZE
+	InfoTxnIsolationOption   = C.SQL_TXN_ISOLATION_OPTION
+	InfoScrollOptions        = C.SQL_SCROLL_OPTIONS
+	InfoBatchSupport         = C.SQL_BATCH_SUPPORT
+	InfoBatchRowCount        = C.SQL_BATCH_ROW_COUNT
+	InfoParamArrayRowCounts  = C.SQL_PARAM_ARRAY_ROW_COUNTS
+	InfoParamArraySelects    = C.SQL_PARAM_ARRAY_SELECTS
+	InfoGetDataExtensions    = C.SQL_GETDATA_EXTENSIONS
+	InfoSchemaUsage          = C.SQL_SCHEMA_USAGE
+	InfoCatalogUsage         = C.SQL_CATALOG_USAGE
+	InfoAsyncMode            = C.SQL_ASYNC_MODE
+)
+
+// InfoKind is the representation of an SQLGetInfo result.
+type InfoKind int
+
+// Kinds of SQLGetInfo results.
+const (
+	InfoString  InfoKind = iota // character string, returned as string.
+	InfoUint16                  // SQLUSMALLINT, returned as uint16.
+	InfoUint32                  // SQLUINTEGER, returned as uint32.
+	InfoBitmask                 // SQLUINTEGER bitmask, returned as Bitmask.
+)
+
+// Bitmask is an SQLGetInfo bitmask result.
+type Bitmask uint32
+
+// Has reports whether all the bits of mask are set.
+func (b Bitmask) Has(mask uint32) bool { return uint32(b)&mask == mask }
+
+var infoKinds = map[int]InfoKind{
+	InfoDataSourceName:       InfoString,
+	InfoServerName:           InfoString,
+	InfoDatabaseName:         InfoString,
+	InfoUserName:             InfoString,
+	InfoDBMSName:             InfoString,
+	InfoDBMSVersion:          InfoString,
+	InfoDriverName:           InfoString,
+	InfoDriverVersion:        InfoString,
+	InfoDriverODBCVersion:    InfoString,
+	InfoODBCVersion:          InfoString,
+	InfoIdentifierQuoteChar:  InfoString,
+	InfoSearchPatternEscape:  InfoString,
+	InfoSpecialCharacters:    InfoString,
+	InfoKeywords:             InfoString,
+	InfoCatalogTerm:          InfoString,
+	InfoCatalogNameSeparator: InfoString,
+	InfoSchemaTerm:           InfoString,
+	InfoTableTerm:            InfoString,
+	InfoProcedureTerm:        InfoString,
+	InfoMultResultSets:       InfoString,
+	InfoDataSourceReadOnly:   InfoString,
+	InfoMaxIdentifierLen:     InfoUint16,
+	InfoMaxColumnNameLen:     InfoUint16,
+	InfoMaxTableNameLen:      InfoUint16,
+	InfoMaxSchemaNameLen:     InfoUint16,
+	InfoMaxCatalogNameLen:    InfoUint16,
+	InfoMaxProcedureNameLen:  InfoUint16,
+	InfoMaxCursorNameLen:     InfoUint16,
+	InfoMaxConcurrentActs:    InfoUint16,
+	InfoMaxDriverConnections: InfoUint16,
+	InfoTxnCapable:           InfoUint16,
+	InfoCursorCommitBehavior: InfoUint16,
+	InfoIdentifierCase:       InfoUint16,
+	InfoQuotedIdentifierCase: InfoUint16,
+	InfoDefaultTxnIsolation:  InfoUint32,
+	InfoMaxStatementLen:      InfoUint32,
+	InfoMaxRowSize:           InfoUint32,
+	InfoParamArrayRowCounts:  InfoUint32,
+	InfoParamArraySelects:    InfoUint32,
+	InfoAsyncMode:            InfoUint32,
+	InfoTxnIsolationOption:   InfoBitmask,
+	InfoScrollOptions:        InfoBitmask,
+	InfoBatchSupport:         InfoBitmask,
+	InfoBatchRowCount:        InfoBitmask,
+	InfoGetDataExtensions:    InfoBitmask,
+	InfoSchemaUsage:          InfoBitmask,
+	InfoCatalogUsage:         InfoBitmask,
+}
+
+// GetInfo returns the value of one of the Info* information types, as a
+// string, uint16, uint32 or Bitmask depending on its kind.
+func (conn *Connection) GetInfo(infoType int) (interface{}, error) {
+	kind, ok := infoKinds[infoType]
+	if !ok {
+		return nil, fmt.Errorf("godbc: unknown kind for info type %d, use GetInfoKind", infoType)
+	}
+	return conn.GetInfoKind(infoType, kind)
+}
+
+// GetInfoKind returns the value of the information type infoType, read as
+// kind. It allows querying information types GetInfo does not know about.
+func (conn *Connection) GetInfoKind(infoType int, kind InfoKind) (interface{}, error) {
+	switch kind {
+	case InfoString:
+		return conn.getInfoString(infoType)
+	case InfoUint16:
+		return conn.getInfoUint16(infoType)
+	case InfoUint32:
+		return conn.getInfoUint32(infoType)
+	case InfoBitmask:
+		n, err := conn.getInfoUint32(infoType)
+		return Bitmask(n), err
+	}
+	return nil, fmt.Errorf("godbc: invalid info kind %d", kind)
+}
+
+func (conn *Connection) getInfoString(infoType int) (string, error) {
+	var (
+		infoLen C.SQLSMALLINT
+		p       = make([]byte, infoBufferLen)
+	)
+
+	for {
+		if ret := C.SQLGetInfo(
+			C.SQLHDBC(conn.Dbc),
+			C.SQLUSMALLINT(infoType),
+			C.SQLPOINTER(unsafe.Pointer(&p[0])),
+			C.SQLSMALLINT(len(p)),
+			&infoLen); !Success(ret) {
+			return "", conn.error()
+		}
+		// On truncation infoLen holds the full length, retry with room
+		// for it and the terminating NUL, up to what a SQLSMALLINT holds.
+		if int(infoLen) < len(p) {
+			return string(p[0:infoLen]), nil
+		}
+		if len(p) >= math.MaxInt16 {
+			return string(p[:len(p)-1]), nil
+		}
+		p = make([]byte, min(int(infoLen)+1, math.MaxInt16))
+	}
+}
+
+func (conn *Connection) getInfoUint16(infoType int) (uint16, error) {
+	var value C.SQLUSMALLINT
+
+	if ret := C.SQLGetInfo(
+		C.SQLHDBC(conn.Dbc),
+		C.SQLUSMALLINT(infoType),
+		C.SQLPOINTER(unsafe.Pointer(&value)),
+		C.SQLSMALLINT(unsafe.Sizeof(value)),
+		nil); !Success(ret) {
+		return 0, conn.error()
+	}
+	return uint16(value), nil
+}
+
+func (conn *Connection) getInfoUint32(infoType int) (uint32, error) {
+	var value C.SQLUINTEGER
+
+	if ret := C.SQLGetInfo(
+		C.SQLHDBC(conn.Dbc),
+		C.SQLUSMALLINT(infoType),
+		C.SQLPOINTER(unsafe.Pointer(&value)),
+		C.SQLSMALLINT(unsafe.Sizeof(value)),
+		nil); !Success(ret) {
+		return 0, conn.error()
+	}
+	return uint32(value), nil
+}
+
+// Capabilities gathers the commonly needed SQLGetInfo values of a
+// connection. Values the driver does not report are left to their zero
+// value.
+type Capabilities struct {
+	DBMSName          string
+	DBMSVersion       string
+	DriverName        string
+	DriverVersion     string
+	DriverODBCVersion string
+
+	IdentifierQuoteChar string // " " if quoting is not supported.
+	SearchPatternEscape string
+	MaxIdentifierLen    int
+	MaxColumnNameLen    int
+	MaxTableNameLen     int
+	MaxSchemaNameLen    int
+	MaxCatalogNameLen   int
+
+	TxnCapable          int     // SQL_TC_NONE, SQL_TC_DML, SQL_TC_ALL, ...
+	TxnIsolationOptions Bitmask // SQL_TXN_READ_UNCOMMITTED, ...
+	DefaultTxnIsolation int
+
+	ScrollOptions       Bitmask // SQL_SO_FORWARD_ONLY, SQL_SO_STATIC, ...
+	BatchSupport        Bitmask // SQL_BS_SELECT_EXPLICIT, ...
+	BatchRowCount       Bitmask // SQL_BRC_PROCEDURES, ...
+	ParamArrayRowCounts int     // SQL_PARC_BATCH or SQL_PARC_NO_BATCH.
+	ParamArraySelects   int     // SQL_PAS_BATCH, SQL_PAS_NO_BATCH or SQL_PAS_NO_SELECT.
+	GetDataExtensions   Bitmask // SQL_GD_ANY_COLUMN, SQL_GD_ANY_ORDER, ...
+	AsyncMode           int     // SQL_AM_NONE, SQL_AM_CONNECTION or SQL_AM_STATEMENT.
+
+	CatalogTerm   string
+	SchemaTerm    string
+	TableTerm     string
+	ProcedureTerm string
+	Keywords      []string // DBMS specific keywords, beyond the ODBC ones.
+}
+
+// Capabilities returns the capabilities of the connection's driver and
+// data source. The result is cached after the first call.
+func (conn *Connection) Capabilities() (*Capabilities, error) {
+	if conn.caps != nil {
+		return conn.caps, nil
+	}
+
+	// SQL_DBMS_NAME is mandatory, failing to read it means the connection
+	// is unusable rather than the driver not reporting it.
+	dbmsName, err := conn.getInfoString(InfoDBMSName)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		c   = &Capabilities{DBMSName: dbmsName}
+		str = func(infoType int) string {
+			s, _ := conn.getInfoString(infoType)
+			return s
+		}
+		u16 = func(infoType int) int {
+			n, _ := conn.getInfoUint16(infoType)
+			return int(n)
+		}
+		u32 = func(infoType int) uint32 {
+			n, _ := conn.getInfoUint32(infoType)
+			return n
+		}
+	)
+
+	c.DBMSVersion = str(InfoDBMSVersion)
+	c.DriverName = str(InfoDriverName)
+	c.DriverVersion = str(InfoDriverVersion)
+	c.DriverODBCVersion = str(InfoDriverODBCVersion)
+
+	c.IdentifierQuoteChar = str(InfoIdentifierQuoteChar)
+	c.SearchPatternEscape = str(InfoSearchPatternEscape)
+	c.MaxIdentifierLen = u16(InfoMaxIdentifierLen)
+	c.MaxColumnNameLen = u16(InfoMaxColumnNameLen)
+	c.MaxTableNameLen = u16(InfoMaxTableNameLen)
+	c.MaxSchemaNameLen = u16(InfoMaxSchemaNameLen)
+	c.MaxCatalogNameLen = u16(InfoMaxCatalogNameLen)
+
+	c.TxnCapable = u16(InfoTxnCapable)
+	c.TxnIsolationOptions = Bitmask(u32(InfoTxnIsolationOption))
+	c.DefaultTxnIsolation = int(u32(InfoDefaultTxnIsolation))
+
+	c.ScrollOptions = Bitmask(u32(InfoScrollOptions))
+	c.BatchSupport = Bitmask(u32(InfoBatchSupport))
+	c.BatchRowCount = Bitmask(u32(InfoBatchRowCount))
+	c.ParamArrayRowCounts = int(u32(InfoParamArrayRowCounts))
+	c.ParamArraySelects = int(u32(InfoParamArraySelects))
+	c.GetDataExtensions = Bitmask(u32(InfoGetDataExtensions))
+	c.AsyncMode = int(u32(InfoAsyncMode))
+
+	c.CatalogTerm = str(InfoCatalogTerm)
+	c.SchemaTerm = str(InfoSchemaTerm)
+	c.TableTerm = str(InfoTableTerm)
+	c.ProcedureTerm = str(InfoProcedureTerm)
+	if kw := str(InfoKeywords); kw != "" {
+		c.Keywords = strings.Split(kw, ",")
+	}
+
+	conn.caps = c
+	return c, nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+)
+
+// StmtState is the state of a Statement in its lifecycle.
+type StmtState int
+
+// Statement states. A statement is allocated, prepared, executed and then,
+// when the execution produced a result set, has its cursor open until
+// CloseCursor, Reset or the next execution. Close frees it for good.
+const (
+	StateAllocated StmtState = iota
+	StatePrepared
+	StateExecuted
+	StateCursorOpen
+	StateClosed
+)
+
+var stmtStateNames = [...]string{"allocated", "prepared", "executed", "cursor open", "closed"}
+
+func (s StmtState) String() string {
+	if s < 0 || int(s) >= len(stmtStateNames) {
+		return fmt.Sprintf("StmtState(%d)", int(s))
+	}
+	return stmtStateNames[s]
+}
+
+// Causes of a StateError.
+var (
+	ErrStmtClosed  = errors.New("godbc: statement is closed")
+	ErrNotPrepared = errors.New("godbc: statement is not prepared")
+	ErrNoCursor    = errors.New("godbc: statement has no open cursor")
+)
+
+// StateError reports a call that the statement's state does not allow,
+// caught before it reaches the driver. It matches its cause with
+// errors.Is.
+type StateError struct {
+	Op    string
+	State StmtState
+	Err   error // ErrStmtClosed, ErrNotPrepared or ErrNoCursor.
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("godbc: cannot %s, statement is %s", e.Op, e.State)
+}
+
+func (e *StateError) Unwrap() error { return e.Err }
+
+// State returns the state of the statement.
+func (stmt *Statement) State() StmtState {
+	switch {
+	case stmt.closed:
+		return StateClosed
+	case stmt.cursorOpen:
+		return StateCursorOpen
+	case stmt.executed:
+		return StateExecuted
+	case stmt.prepared:
+		return StatePrepared
+	}
+	return StateAllocated
+}
+
+// CloseCursor closes the cursor of the statement and discards the pending
+// results, if any. The statement can then be executed again.
+func (stmt *Statement) CloseCursor() error {
+	if stmt.closed {
+		return &StateError{Op: "close cursor", State: StateClosed, Err: ErrStmtClosed}
+	}
+	if !stmt.cursorOpen && !stmt.executed {
+		return nil
+	}
+	// Results may be pending even without a cursor, e.g. after an UPDATE
+	// starting a batch. Unlike SQLCloseCursor, SQL_CLOSE does not fail
+	// when the driver has no cursor.
+	if ret := C.SQLFreeStmt(C.SQLHSTMT(stmt.handle), C.SQL_CLOSE); !Success(ret) {
+		return stmt.error()
+	}
+	stmt.cursorOpen = false
+	stmt.executed = false
+	return nil
+}
+
+// Reset closes the cursor, unbinds the columns and parameters and brings
+// the statement back to its prepared state.
+func (stmt *Statement) Reset() error {
+	if stmt.closed {
+		return &StateError{Op: "reset", State: StateClosed, Err: ErrStmtClosed}
+	}
+	for _, option := range []C.SQLUSMALLINT{C.SQL_CLOSE, C.SQL_UNBIND, C.SQL_RESET_PARAMS} {
+		if ret := C.SQLFreeStmt(C.SQLHSTMT(stmt.handle), option); !Success(ret) {
+			return stmt.error()
+		}
+	}
+	stmt.cursorOpen = false
+	stmt.executed = false
+	stmt.fetched = 0
+	stmt.withInfo = false
+	stmt.warnings = nil
+	return nil
+}
+
+// checkOpen fails when the statement is closed.
+func (stmt *Statement) checkOpen(op string) error {
+	if stmt.closed {
+		return &StateError{Op: op, State: StateClosed, Err: ErrStmtClosed}
+	}
+	return nil
+}
+
+// checkExecute prepares the statement to be executed again, closing its
+// cursor.
+func (stmt *Statement) checkExecute() error {
+	if err := stmt.checkOpen("execute"); err != nil {
+		return err
+	}
+	if !stmt.prepared {
+		return &StateError{Op: "execute", State: stmt.State(), Err: ErrNotPrepared}
+	}
+	return stmt.CloseCursor()
+}
+
+// checkResults fails when the statement has not been executed, and so has
+// no results.
+func (stmt *Statement) checkResults(op string) error {
+	if err := stmt.checkOpen(op); err != nil {
+		return err
+	}
+	if !stmt.executed {
+		return &StateError{Op: op, State: stmt.State(), Err: ErrNoCursor}
+	}
+	return nil
+}
+
+// hasResultSet reports whether the current result of the statement is a
+// result set, rather than the row count of a statement returning no rows.
+func (stmt *Statement) hasResultSet() bool {
+	var n C.SQLSMALLINT
+	ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLNumResultCols(C.SQLHSTMT(stmt.handle), &n)
+	})
+	return Success(ret) && n > 0
+}
+
+// checkCursor fails when there is no cursor to fetch from.
+func (stmt *Statement) checkCursor(op string) error {
+	if err := stmt.checkOpen(op); err != nil {
+		return err
+	}
+	if !stmt.cursorOpen {
+		return &StateError{Op: op, State: stmt.State(), Err: ErrNoCursor}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClosedStatement(t *testing.T) {
+	stmt := &Statement{closed: true}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"Execute", func() error { return stmt.Execute() }},
+		{"Fetch", func() error { _, err := stmt.Fetch(); return err }},
+		{"NextResultContext", func() error { _, err := stmt.NextResultContext(context.Background()); return err }},
+		{"CloseCursor", stmt.CloseCursor},
+		{"Reset", stmt.Reset},
+		{"RowsAffected", func() error { _, err := stmt.RowsAffected(); return err }},
+		{"Cancel", stmt.Cancel},
+		{"GetParamType", func() error { _, _, _, _, err := stmt.GetParamType(1); return err }},
+		{"BindParam", func() error { return stmt.BindParam(1, 1) }},
+		{"NumRows", func() error { _, err := stmt.NumRows(); return err }},
+		{"FieldMetadata", func() error { _, err := stmt.FieldMetadata(1); return err }},
+		{"DatabaseTypeName", func() error { _, err := stmt.DatabaseTypeName(1); return err }},
+		{"Bookmark", func() error { _, err := stmt.Bookmark(); return err }},
+		{"SetCursorName", func() error { return stmt.SetCursorName("c") }},
+		{"CursorName", func() error { _, err := stmt.CursorName(); return err }},
+		{"UpdateCurrent", func() error { return stmt.UpdateCurrent(1) }},
+		{"DeleteCurrent", stmt.DeleteCurrent},
+		{"RefreshCurrent", stmt.RefreshCurrent},
+		{"BulkAdd", func() error { return stmt.BulkAdd([][]interface{}{{1}}) }},
+	}
+	for _, tt := range tests {
+		err := tt.call()
+		if !errors.Is(err, ErrStmtClosed) {
+			t.Errorf("%s on a closed statement: got %v, want ErrStmtClosed", tt.name, err)
+		}
+		var se *StateError
+		if errors.As(err, &se) && se.State != StateClosed {
+			t.Errorf("%s on a closed statement: state %v, want %v", tt.name, se.State, StateClosed)
+		}
+	}
+
+	if n := stmt.NumParams(); n != -1 {
+		t.Errorf("NumParams on a closed statement = %d, want -1", n)
+	}
+}
+
+func TestPositionedWithoutCursor(t *testing.T) {
+	stmt := &Statement{executed: true}
+
+	for name, call := range map[string]func() error{
+		"UpdateCurrent":  func() error { return stmt.UpdateCurrent(1) },
+		"DeleteCurrent":  stmt.DeleteCurrent,
+		"RefreshCurrent": stmt.RefreshCurrent,
+		"BulkAdd":        func() error { return stmt.BulkAdd([][]interface{}{{1}}) },
+	} {
+		if err := call(); !errors.Is(err, ErrNoCursor) {
+			t.Errorf("%s without a cursor: got %v, want ErrNoCursor", name, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	SetLogger(nil)
+}
+
+// SetLogger sets the logger the package reports its internal decisions
+// to, such as driver feature fallbacks. Debug level is used for those. A
+// nil logger, the default, discards everything.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	logger.Store(l)
+}
+
+// Logger returns the logger set with SetLogger.
+func Logger() *slog.Logger {
+	return logger.Load()
+}
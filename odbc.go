@@ -43,9 +43,14 @@ SQLRETURN _SQLColAttribute (
 */
 import "C"
 import (
+	"context"
 	"database/sql/driver"
 	"fmt"
+	"math"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 )
@@ -62,70 +67,348 @@ const (
 )
 
 var (
-	Genv C.SQLHANDLE
+	Genv   C.SQLHANDLE
+	odbc38 atomic.Bool // the driver manager accepted ODBC 3.8.
 )
 
 type Connection struct {
-	Dbc       C.SQLHANDLE
-	connected bool
+	Dbc        C.SQLHANDLE
+	connected  bool
+	outConnStr string
+	cfg        *config
+
+	typeInfoMu   sync.Mutex  // guards typeInfo.
+	typeInfo     []*TypeInfo // nil until read, empty if the data source has no types.
+	caps         *Capabilities
+	functions    *FunctionSet
+	functionsErr error // SQLGetFunctions failed.
+	ansi         bool  // the driver lacks the W functions.
+	asyncDbc     bool  // SQL_ATTR_ASYNC_DBC_FUNCTIONS_ENABLE is usable.
+	onInfo       func(*Diagnostic)
+	dbms         dbmsKind
+	stmtCache    *stmtCache
+	lost         atomic.Bool // an error told the connection was lost.
 }
 
 type Statement struct {
 	executed   bool
 	prepared   bool
 	scrollable bool
-
-	handle C.SQLHANDLE
+	async      bool
+	cursorOpen bool // the current result is a result set.
+	closed     bool
+	withInfo   bool // the last call returned SQL_SUCCESS_WITH_INFO.
+
+	handle   C.SQLHANDLE
+	conn     *Connection
+	fetched  int            // rows fetched so far, when SQLRowCount cannot tell.
+	bookmark unsafe.Pointer // C copy of the FetchBookmark argument.
+	warnings []*Diagnostic
+	cacheKey string // the SQL text, for statements of PrepareCached.
+	cacheGen int
 }
 
 type Error struct {
-	SQLState     string
-	NativeError  int
-	ErrorMessage string
+	SQLState     string // of the first diagnostic record.
+	NativeError  int    // of the first diagnostic record.
+	ErrorMessage string // the messages of all records, concatenated.
+
+	// Diagnostics holds every diagnostic record in the driver's order,
+	// the most important first.
+	Diagnostics []*Diagnostic
+
+	// Header fields of the diagnostic area.
+	Number              int    // SQL_DIAG_NUMBER, the number of records.
+	DynamicFunction     string // SQL_DIAG_DYNAMIC_FUNCTION, e.g. "UPDATE WHERE".
+	DynamicFunctionCode int    // SQL_DIAG_DYNAMIC_FUNCTION_CODE.
+
+	err  error    // the context error behind a timeout or cancellation.
+	dbms dbmsKind // to interpret NativeError, see Is.
 }
 
 func (e *Error) Error() string {
-	if e != nil {
+	if e == nil {
+		return ""
+	}
+	if len(e.Diagnostics) <= 1 {
 		return e.SQLState + " " + e.ErrorMessage
 	}
-	return ""
+	var b strings.Builder
+	for i, d := range e.Diagnostics {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(d.SQLState + " " + d.Message)
+	}
+	return b.String()
 }
 func (e *Error) String() string { return e.Error() }
 
+// Unwrap returns the context error that caused the statement to time out
+// or be cancelled, if any.
+func (e *Error) Unwrap() error { return e.err }
+
 func initEnv() error {
 	if ret := C.SQLAllocHandle(C.SQL_HANDLE_ENV, nil, &Genv); !Success(ret) {
 		return FormatError(C.SQL_HANDLE_ENV, Genv)
 	}
+	// ODBC 3.8 is needed for asynchronous connection functions, older
+	// driver managers only know ODBC 3.0.
+	if ret := C.SQLSetEnvAttr(C.SQLHENV(Genv), C.SQL_ATTR_ODBC_VERSION, C.SQLPOINTER(unsafe.Pointer(uintptr(C.SQL_OV_ODBC3_80))), C.SQLINTEGER(0)); Success(ret) {
+		odbc38.Store(true)
+		return nil
+	}
 	if ret := C.SQLSetEnvAttr(C.SQLHENV(Genv), C.SQL_ATTR_ODBC_VERSION, C.SQLPOINTER(unsafe.Pointer(uintptr(C.SQL_OV_ODBC3))), C.SQLINTEGER(0)); !Success(ret) {
 		return FormatError(C.SQL_HANDLE_ENV, Genv)
 	}
 	return nil
 }
 
+// Connect opens a connection with the ODBC connection string dsn. Package
+// options (see OptionPrefix) are removed from it before it reaches the
+// driver. params may hold Attr values to set before connecting and a
+// func(*Diagnostic) handler, see Connection.OnInfo.
 func Connect(dsn string, params ...interface{}) (*Connection, error) {
-	var h C.SQLHANDLE
+	return ConnectContext(context.Background(), dsn, params...)
+}
+
+// ConnectContext is like Connect but bounds the login time with the
+// context deadline and gives up when the context is done. The abandoned
+// attempt is cleaned up once SQLDriverConnect returns.
+func ConnectContext(ctx context.Context, dsn string, params ...interface{}) (*Connection, error) {
+	dsn, cfg, err := splitOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	loginTimeout := cfg.loginTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		if loginTimeout == 0 || d < loginTimeout {
+			loginTimeout = d
+		}
+	}
+	h, onInfo, err := allocConn(loginTimeout, cfg.connectionTimeout, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// With asynchronous connection functions the attempt is polled and
+	// cancelled in place, otherwise it runs in a goroutine that is
+	// abandoned when ctx is done.
+	if err := enableAsyncDbc(h, true); err == nil {
+		conn, err := driverConnect(ctx, h, dsn, cfg, onInfo, true)
+		if err != errNoAsyncDbc {
+			return conn, err
+		}
+	}
+	if ctx.Done() == nil {
+		return driverConnect(ctx, h, dsn, cfg, onInfo, false)
+	}
+
+	type result struct {
+		conn *Connection
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := driverConnect(context.Background(), h, dsn, cfg, onInfo, false)
+		done <- result{conn, err}
+	}()
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// splitOptions removes the package options from the connection string
+// dsn, returning what is left for the driver and the parsed options.
+func splitOptions(dsn string) (string, *config, error) {
+	cs, err := ParseConnString(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	cfg, err := parseConfig(cs.Options())
+	if err != nil {
+		return "", nil, err
+	}
+	return cs.DriverString(), cfg, nil
+}
+
+// allocConn allocates a connection handle and sets the timeouts and the
+// Attr values of params on it. It returns the handler found in params.
+func allocConn(loginTimeout, connectionTimeout time.Duration, params []interface{}) (C.SQLHANDLE, func(*Diagnostic), error) {
+	var (
+		h      C.SQLHANDLE
+		onInfo func(*Diagnostic)
+	)
 
 	if ret := C.SQLAllocHandle(C.SQL_HANDLE_DBC, Genv, &h); !Success(ret) {
-		return nil, FormatError(C.SQL_HANDLE_DBC, h)
+		return nil, nil, FormatError(C.SQL_HANDLE_ENV, Genv)
+	}
+	for _, attr := range []struct {
+		id C.SQLINTEGER
+		d  time.Duration
+	}{
+		{C.SQL_ATTR_LOGIN_TIMEOUT, loginTimeout},
+		{C.SQL_ATTR_CONNECTION_TIMEOUT, connectionTimeout},
+	} {
+		if attr.d == 0 {
+			continue
+		}
+		if ret := C.SQLSetConnectAttr(
+			C.SQLHDBC(h),
+			attr.id,
+			C.SQLPOINTER(unsafe.Pointer(seconds(attr.d))),
+			C.SQL_IS_UINTEGER); !Success(ret) {
+			err := FormatError(C.SQL_HANDLE_DBC, h)
+			C.SQLFreeHandle(C.SQL_HANDLE_DBC, h)
+			return nil, nil, err
+		}
+	}
+	for _, p := range params {
+		var err error
+		switch v := p.(type) {
+		case Attr:
+			_, err = setConnectAttr(h, v.ID, v.Value)
+		case func(*Diagnostic):
+			onInfo = v
+		default:
+			err = fmt.Errorf("godbc: unsupported Connect parameter %T", p)
+		}
+		if err != nil {
+			C.SQLFreeHandle(C.SQL_HANDLE_DBC, h)
+			return nil, nil, err
+		}
+	}
+	return h, onInfo, nil
+}
+
+// setup readies conn once its handle is connected, info holding the
+// diagnostics of the connection call.
+func (conn *Connection) setup(info []*Diagnostic) {
+	if conn.cfg.stmtCacheSize > 0 {
+		conn.stmtCache = newStmtCache(conn.cfg.stmtCacheSize)
 	}
+	conn.detectDBMS()
+	conn.notify(info)
+}
 
+// driverConnect connects the allocated handle h, freeing it on failure.
+// When async is true the connection functions of h run asynchronously and
+// are cancelled once ctx is done. If the driver turns out not to support
+// that, h is switched back to synchronous mode and kept, and
+// errNoAsyncDbc is returned.
+func driverConnect(ctx context.Context, h C.SQLHANDLE, dsn string, cfg *config, onInfo func(*Diagnostic), async bool) (*Connection, error) {
 	var (
-		stringLength2       C.SQLSMALLINT
-		outBuf              = make([]byte, bufferSize*2)
-		outConnectionString = (*C.SQLCHAR)(unsafe.Pointer(&outBuf[0]))
+		outLen C.SQLSMALLINT
+		outBuf = make([]byte, bufferSize)
+		in     = append([]byte(dsn), 0)
+		conn   = &Connection{Dbc: h, cfg: cfg, onInfo: onInfo}
+		info   []*Diagnostic
 	)
-	dsn += "\x00"
-	if ret := C.SQLDriverConnect(C.SQLHDBC(h),
-		nil,
-		(*C.SQLCHAR)(unsafe.Pointer(&[]byte(dsn)[0])),
-		C.SQL_NTS,
-		outConnectionString,
-		bufferSize,
-		&stringLength2,
-		C.SQL_DRIVER_NOPROMPT); !Success(ret) {
-		return nil, FormatError(C.SQL_HANDLE_DBC, h)
+	for {
+		ret := pollDbc(ctx, h, async, func() C.SQLRETURN {
+			return C.SQLDriverConnect(C.SQLHDBC(h),
+				nil,
+				(*C.SQLCHAR)(unsafe.Pointer(&in[0])),
+				C.SQL_NTS,
+				(*C.SQLCHAR)(unsafe.Pointer(&outBuf[0])),
+				C.SQLSMALLINT(len(outBuf)),
+				&outLen,
+				C.SQL_DRIVER_NOPROMPT)
+		})
+		if !Success(ret) {
+			err := contextError(ctx, FormatError(C.SQL_HANDLE_DBC, h), false)
+			if async && !conn.connected && asyncDbcUnsupported(err) {
+				Logger().Debug("godbc: asynchronous connection functions not supported, connecting synchronously")
+				if enableAsyncDbc(h, false) == nil {
+					return nil, errNoAsyncDbc
+				}
+			}
+			C.SQLFreeHandle(C.SQL_HANDLE_DBC, h)
+			return nil, err
+		}
+		conn.connected = true
+		// Read now, the calls below clear them.
+		if ret == C.SQL_SUCCESS_WITH_INFO && conn.wantsInfo() {
+			info = diagnostics(C.SQL_HANDLE_DBC, h)
+		}
+		if int(outLen) < len(outBuf) || len(outBuf) >= math.MaxInt16 {
+			break
+		}
+		// 01004: the completed connection string was truncated, the only
+		// way to get it whole is to connect again with a larger buffer.
+		Logger().Debug("godbc: completed connection string truncated, reconnecting", "length", int(outLen))
+		if ret := pollDbc(ctx, h, async, func() C.SQLRETURN {
+			return C.SQLDisconnect(C.SQLHDBC(h))
+		}); !Success(ret) {
+			err := FormatError(C.SQL_HANDLE_DBC, h)
+			C.SQLFreeHandle(C.SQL_HANDLE_DBC, h)
+			return nil, err
+		}
+		outBuf = make([]byte, min(int(outLen)+1, math.MaxInt16))
+	}
+	if int(outLen) >= len(outBuf) {
+		outLen = C.SQLSMALLINT(len(outBuf) - 1)
+	}
+	if async {
+		// Keep commits and attributes synchronous, Close turns it back on
+		// if the driver says it can.
+		if err := enableAsyncDbc(h, false); err != nil {
+			Logger().Debug("godbc: cannot disable asynchronous connection functions", "err", err)
+		}
+		n, err := conn.getInfoUint32(C.SQL_ASYNC_DBC_FUNCTIONS)
+		conn.asyncDbc = err == nil && n == C.SQL_ASYNC_DBC_CAPABLE
 	}
-	return &Connection{Dbc: h, connected: true}, nil
+	conn.outConnStr = string(outBuf[:outLen])
+	conn.setup(info)
+	return conn, nil
+}
+
+// ConnectionString returns the connection string completed by the driver
+// when the connection was established, which tells the driver, server and
+// database the DSN resolved to. Passwords and other secrets are masked,
+// use RawConnectionString to get them.
+func (conn *Connection) ConnectionString() string {
+	cs, err := ParseConnString(conn.outConnStr)
+	if err != nil {
+		return ""
+	}
+	for _, k := range cs.Keys() {
+		if isSecretKey(k) {
+			cs.Set(k, "*****")
+		}
+	}
+	return cs.String()
+}
+
+// RawConnectionString returns the connection string completed by the
+// driver, secrets included.
+func (conn *Connection) RawConnectionString() string {
+	return conn.outConnStr
+}
+
+// isSecretKey reports whether the connection string keyword k holds a
+// secret that ConnectionString must not reveal.
+func isSecretKey(k string) bool {
+	k = strings.ToLower(k)
+	for _, s := range []string{"pwd", "password", "secret", "token"} {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
 }
 
 func (conn *Connection) ExecDirect(sql string) (*Statement, error) {
@@ -133,41 +416,66 @@ func (conn *Connection) ExecDirect(sql string) (*Statement, error) {
 	if err != nil {
 		return nil, err
 	}
-	sql += "\x00"
-	if ret := C.SQLExecDirect(
-		C.SQLHSTMT(stmt.handle),
-		(*C.SQLCHAR)(unsafe.Pointer(&[]byte(sql)[0])),
-		C.SQL_NTS); !Success(ret) {
-		err := FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	ret := stmt.callW(func() C.SQLRETURN {
+		return C.SQLExecDirectW(
+			C.SQLHSTMT(stmt.handle),
+			(*C.SQLWCHAR)(unsafe.Pointer(StringToUTF16Ptr(sql))),
+			C.SQL_NTS)
+	}, func() C.SQLRETURN {
+		return C.SQLExecDirect(
+			C.SQLHSTMT(stmt.handle),
+			(*C.SQLCHAR)(unsafe.Pointer(&[]byte(sql + "\x00")[0])),
+			C.SQL_NTS)
+	})
+	// SQL_NO_DATA: a searched UPDATE or DELETE that affected no rows.
+	if ret != C.SQL_NO_DATA && !Success(ret) {
+		err := stmt.error()
 		stmt.Close()
 		return nil, err
 	}
+	stmt.checkInfo(ret)
 	stmt.executed = true
+	stmt.cursorOpen = stmt.hasResultSet()
 	return stmt, nil
 }
 
 func (conn *Connection) newStmt() (*Statement, error) {
-	stmt := &Statement{}
+	stmt := &Statement{conn: conn}
 
 	if ret := C.SQLAllocHandle(C.SQL_HANDLE_STMT, conn.Dbc, &stmt.handle); !Success(ret) {
-		return nil, FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+		return nil, conn.error()
+	}
+	if err := stmt.applyDefaults(conn.cfg); err != nil {
+		stmt.Close()
+		return nil, err
 	}
 	return stmt, nil
 }
 
+// Prepare prepares sql for execution. params may hold statement options
+// such as a CursorType or a Concurrency.
 func (conn *Connection) Prepare(sql string, params ...interface{}) (*Statement, error) {
-	sql += "\x00"
-
 	stmt, err := conn.newStmt()
 	if err != nil {
 		return nil, err
 	}
+	if err := stmt.setOptions(params); err != nil {
+		stmt.Close()
+		return nil, err
+	}
 
-	if ret := C.SQLPrepareW(
-		C.SQLHSTMT(stmt.handle),
-		(*C.SQLWCHAR)(unsafe.Pointer(&[]byte(sql)[0])),
-		C.SQLINTEGER(len(sql))); !Success(ret) {
-		err := FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	if ret := stmt.callW(func() C.SQLRETURN {
+		return C.SQLPrepareW(
+			C.SQLHSTMT(stmt.handle),
+			(*C.SQLWCHAR)(unsafe.Pointer(StringToUTF16Ptr(sql))),
+			C.SQL_NTS)
+	}, func() C.SQLRETURN {
+		return C.SQLPrepare(
+			C.SQLHSTMT(stmt.handle),
+			(*C.SQLCHAR)(unsafe.Pointer(&[]byte(sql + "\x00")[0])),
+			C.SQL_NTS)
+	}); !Success(ret) {
+		err := stmt.error()
 		stmt.Close()
 		return nil, err
 	}
@@ -176,9 +484,11 @@ func (conn *Connection) Prepare(sql string, params ...interface{}) (*Statement,
 }
 
 func (conn *Connection) Commit() error {
-	if ret := C.SQLEndTran(C.SQL_HANDLE_DBC, conn.Dbc, C.SQL_COMMIT); !Success(ret) {
-		return FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	ret := C.SQLEndTran(C.SQL_HANDLE_DBC, conn.Dbc, C.SQL_COMMIT)
+	if !Success(ret) {
+		return conn.error()
 	}
+	conn.checkInfo(ret, C.SQL_HANDLE_DBC, conn.Dbc)
 	return nil
 }
 
@@ -195,7 +505,7 @@ func (conn *Connection) AutoCommit(b bool) error {
 		C.SQL_ATTR_AUTOCOMMIT,
 		C.SQLPOINTER(unsafe.Pointer(uintptr(n))),
 		C.SQL_IS_UINTEGER); !Success(ret) {
-		return FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+		return conn.error()
 	}
 	return nil
 }
@@ -206,107 +516,67 @@ func (conn *Connection) BeginTransaction() error {
 		C.SQL_ATTR_AUTOCOMMIT,
 		C.SQLPOINTER(unsafe.Pointer(uintptr(C.SQL_AUTOCOMMIT_OFF))),
 		C.SQL_IS_UINTEGER); !Success(ret) {
-		return FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+		return conn.error()
 	}
 	return nil
 }
 
 func (conn *Connection) Rollback() error {
-	if ret := C.SQLEndTran(C.SQL_HANDLE_DBC, conn.Dbc, C.SQL_ROLLBACK); !Success(ret) {
-		return FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	ret := C.SQLEndTran(C.SQL_HANDLE_DBC, conn.Dbc, C.SQL_ROLLBACK)
+	if !Success(ret) {
+		return conn.error()
 	}
+	conn.checkInfo(ret, C.SQL_HANDLE_DBC, conn.Dbc)
 	return nil
 }
 
 // ServerInfo fetch info regarding the underlying database server
 func (conn *Connection) ServerInfo() (dbName, dbVersion, serverName string, err error) {
-	var (
-		infoLen C.SQLSMALLINT
-		p       = make([]byte, infoBufferLen)
-	)
-
-	if ret := C.SQLGetInfo(
-		C.SQLHDBC(conn.Dbc),
-		C.SQL_DATABASE_NAME,
-		C.SQLPOINTER(unsafe.Pointer(&p[0])),
-		infoBufferLen,
-		&infoLen); !Success(ret) {
-		return "", "", "", FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	if dbName, err = conn.getInfoString(InfoDatabaseName); err != nil {
+		return "", "", "", err
 	}
-	dbName = string(p[0:infoLen])
-
-	if ret := C.SQLGetInfo(
-		C.SQLHDBC(conn.Dbc),
-		C.SQL_DBMS_VER,
-		C.SQLPOINTER(unsafe.Pointer(&p[0])),
-		infoBufferLen,
-		&infoLen); !Success(ret) {
-		return dbName, "", "", FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	if dbVersion, err = conn.getInfoString(InfoDBMSVersion); err != nil {
+		return dbName, "", "", err
 	}
-	dbVersion = string(p[0:infoLen])
-
-	if ret := C.SQLGetInfo(
-		C.SQLHDBC(conn.Dbc),
-		C.SQL_SERVER_NAME,
-		C.SQLPOINTER(unsafe.Pointer(&p[0])),
-		infoBufferLen,
-		&infoLen); !Success(ret) {
-		return dbName, dbVersion, "", FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	if serverName, err = conn.getInfoString(InfoServerName); err != nil {
+		return dbName, dbVersion, "", err
 	}
-	serverName = string(p[0:infoLen])
-
 	return dbName, dbVersion, serverName, nil
 }
 
 // ClientInfo fetch info regarding the client's driver.
 func (conn *Connection) ClientInfo() (driverName string, odbcVersion string, driverVersion string, err error) {
-	var (
-		infoLen C.SQLSMALLINT
-		p       = make([]byte, infoBufferLen)
-	)
-
-	if ret := C.SQLGetInfo(
-		C.SQLHDBC(conn.Dbc),
-		C.SQL_DRIVER_NAME,
-		C.SQLPOINTER(unsafe.Pointer(&p[0])),
-		infoBufferLen,
-		&infoLen); !Success(ret) {
-		return "", "", "", FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	if driverName, err = conn.getInfoString(InfoDriverName); err != nil {
+		return "", "", "", err
 	}
-	driverName = string(p[0:infoLen])
-
-	if ret := C.SQLGetInfo(
-		C.SQLHDBC(conn.Dbc),
-		C.SQL_DRIVER_ODBC_VER,
-		C.SQLPOINTER(unsafe.Pointer(&p[0])),
-		infoBufferLen,
-		&infoLen); !Success(ret) {
-		return "", "", "", FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	if odbcVersion, err = conn.getInfoString(InfoDriverODBCVersion); err != nil {
+		return "", "", "", err
 	}
-	odbcVersion = string(p[0:infoLen])
-
-	if ret := C.SQLGetInfo(
-		C.SQLHDBC(conn.Dbc),
-		C.SQL_DRIVER_VER,
-		C.SQLPOINTER(unsafe.Pointer(&p[0])),
-		infoBufferLen,
-		&infoLen); !Success(ret) {
-		err := FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+	if driverVersion, err = conn.getInfoString(InfoDriverVersion); err != nil {
 		return "", "", "", err
 	}
-	driverVersion = string(p[0:infoLen])
-
 	return driverName, odbcVersion, driverVersion, nil
 }
 
+// Close disconnects and frees the connection. When the driver supports
+// asynchronous connection functions, the disconnection is polled and may
+// be aborted with Cancel.
 func (conn *Connection) Close() error {
 	if conn.connected {
-		if ret := C.SQLDisconnect(C.SQLHDBC(conn.Dbc)); !Success(ret) {
-			return FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+		conn.InvalidateStmtCache()
+		async := conn.asyncDbc && enableAsyncDbc(conn.Dbc, true) == nil
+		if ret := pollDbc(context.Background(), conn.Dbc, async, func() C.SQLRETURN {
+			return C.SQLDisconnect(C.SQLHDBC(conn.Dbc))
+		}); !Success(ret) {
+			err := conn.error()
+			if async {
+				enableAsyncDbc(conn.Dbc, false)
+			}
+			return err
 		}
 
 		if ret := C.SQLFreeHandle(C.SQL_HANDLE_DBC, conn.Dbc); !Success(ret) {
-			return FormatError(C.SQL_HANDLE_DBC, conn.Dbc)
+			return conn.error()
 		}
 		conn.connected = false
 	}
@@ -316,16 +586,29 @@ func (conn *Connection) Close() error {
 func (stmt *Statement) RowsAffected() (int, error) {
 	var nor C.SQLLEN
 
+	if err := stmt.checkOpen("count affected rows"); err != nil {
+		return -1, err
+	}
+
 	if ret := C.SQLRowCount(C.SQLHSTMT(stmt.handle), &nor); !Success(ret) {
-		return -1, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+		return -1, stmt.error()
 	}
 	return int(nor), nil
 }
 
 func (stmt *Statement) Cancel() error {
+	if err := stmt.checkOpen("cancel"); err != nil {
+		return err
+	}
+
 	ret := C.SQLCancel(C.SQLHSTMT(stmt.handle))
 	if !Success(ret) {
+		// Not stmt.error: Cancel runs in other goroutines, see
+		// ExecuteContext, and must leave the statement cache alone.
 		err := FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+		if e, ok := err.(*Error); ok {
+			e.dbms = stmt.conn.dbms
+		}
 		return err
 	}
 	return nil
@@ -334,70 +617,88 @@ func (stmt *Statement) Cancel() error {
 func (stmt *Statement) NumParams() int {
 	var cParams C.SQLSMALLINT
 
-	if ret := C.SQLNumParams(C.SQLHSTMT(stmt.handle), &cParams); !Success(ret) {
+	if stmt.checkOpen("count parameters") != nil {
+		return -1
+	}
+
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLNumParams(C.SQLHSTMT(stmt.handle), &cParams)
+	}); !Success(ret) {
 		return -1
 	}
 	return int(cParams)
 }
 
 func (stmt *Statement) Execute(params ...interface{}) error {
+	return stmt.execute(context.Background(), params)
+}
+
+func (stmt *Statement) execute(ctx context.Context, params []interface{}) error {
+	if err := stmt.checkExecute(); err != nil {
+		return err
+	}
 	if params != nil {
 		var cParams C.SQLSMALLINT
 
-		if ret := C.SQLNumParams(C.SQLHSTMT(stmt.handle), &cParams); !Success(ret) {
-			err := FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+		if ret := stmt.wait(func() C.SQLRETURN {
+			return C.SQLNumParams(C.SQLHSTMT(stmt.handle), &cParams)
+		}); !Success(ret) {
+			err := stmt.error()
 			return err
 		}
 		for i := 0; i < int(cParams); i++ {
 			stmt.BindParam(i+1, params[i])
 		}
 	}
-	ret := C.SQLExecute(C.SQLHSTMT(stmt.handle))
+	ret, err := stmt.poll(ctx, func() C.SQLRETURN {
+		return C.SQLExecute(C.SQLHSTMT(stmt.handle))
+	})
 	if ret == C.SQL_NEED_DATA {
 		// TODO
 		//		send_data(stmt)
 	} else if ret == C.SQL_NO_DATA {
 		// Execute NO DATA
-	} else if !Success(ret) {
-		return FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	} else if err != nil {
+		return err
 	}
 	stmt.executed = true
+	stmt.cursorOpen = stmt.hasResultSet()
+	stmt.fetched = 0
 	return nil
 }
 
 func (stmt *Statement) Execute2(params []driver.Value) error {
-	if params != nil {
-		var cParams C.SQLSMALLINT
+	var p []interface{}
 
-		if ret := C.SQLNumParams(C.SQLHSTMT(stmt.handle), &cParams); !Success(ret) {
-			err := FormatError(C.SQL_HANDLE_STMT, stmt.handle)
-			return err
-		}
-		for i := 0; i < int(cParams); i++ {
-			stmt.BindParam(i+1, params[i])
+	if params != nil {
+		p = make([]interface{}, len(params))
+		for i, v := range params {
+			p[i] = v
 		}
 	}
-	if ret := C.SQLExecute(C.SQLHSTMT(stmt.handle)); ret == C.SQL_NEED_DATA {
-		// TODO
-		//		send_data(stmt)
-	} else if ret == C.SQL_NO_DATA {
-		// Execute NO DATA
-	} else if !Success(ret) {
-		err := FormatError(C.SQL_HANDLE_STMT, stmt.handle)
-		return err
-	}
-	stmt.executed = true
-	return nil
+	return stmt.execute(context.Background(), p)
 }
 
 func (stmt *Statement) Fetch() (bool, error) {
-	ret := C.SQLFetch(C.SQLHSTMT(stmt.handle))
+	return stmt.FetchContext(context.Background())
+}
+
+// FetchContext is like Fetch but gives up when ctx is done, provided the
+// statement is asynchronous, see SetAsync.
+func (stmt *Statement) FetchContext(ctx context.Context) (bool, error) {
+	if err := stmt.checkCursor("fetch"); err != nil {
+		return false, err
+	}
+	ret, err := stmt.poll(ctx, func() C.SQLRETURN {
+		return C.SQLFetch(C.SQLHSTMT(stmt.handle))
+	})
+	if err != nil {
+		return false, contextError(ctx, err, false)
+	}
 	if ret == C.SQL_NO_DATA {
 		return false, nil
 	}
-	if !Success(ret) {
-		return false, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
-	}
+	stmt.fetched++
 	return true, nil
 }
 
@@ -493,7 +794,12 @@ func (stmt *Statement) FetchOne() (*Row, error) {
 }
 
 func (stmt *Statement) FetchOne2(row []driver.Value) (eof bool, err error) {
-	ok, err := stmt.Fetch()
+	return stmt.FetchOne2Context(context.Background(), row)
+}
+
+// FetchOne2Context is like FetchOne2 but fetches with FetchContext.
+func (stmt *Statement) FetchOne2Context(ctx context.Context, row []driver.Value) (eof bool, err error) {
+	ok, err := stmt.FetchContext(ctx)
 	if !ok && err == nil {
 		return !ok, nil
 	} else if err != nil {
@@ -515,25 +821,33 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		ll        C.SQLSMALLINT
 	)
 
-	if ret := C._SQLColAttribute(
-		C.SQLHSTMT(stmt.handle),
-		C.SQLUSMALLINT(fieldIndex+1),
-		C.SQL_DESC_CONCISE_TYPE,
-		nil,
-		C.SQLSMALLINT(0),
-		&ll,
-		unsafe.Pointer(&fieldType)); !Success(ret) {
-		return nil, -1, -1, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
-	}
-	if ret := C._SQLColAttribute(
-		C.SQLHSTMT(stmt.handle),
-		C.SQLUSMALLINT(fieldIndex+1),
-		C.SQL_DESC_LENGTH,
-		nil,
-		C.SQLSMALLINT(0),
-		&ll,
-		unsafe.Pointer(&fieldLen)); !Success(ret) {
-		return nil, -1, -1, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	if err := stmt.checkCursor("get field"); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C._SQLColAttribute(
+			C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(fieldIndex+1),
+			C.SQL_DESC_CONCISE_TYPE,
+			nil,
+			C.SQLSMALLINT(0),
+			&ll,
+			unsafe.Pointer(&fieldType))
+	}); !Success(ret) {
+		return nil, -1, -1, stmt.error()
+	}
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C._SQLColAttribute(
+			C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(fieldIndex+1),
+			C.SQL_DESC_LENGTH,
+			nil,
+			C.SQLSMALLINT(0),
+			&ll,
+			unsafe.Pointer(&fieldLen))
+	}); !Success(ret) {
+		return nil, -1, -1, stmt.error()
 	}
 
 	var (
@@ -544,7 +858,9 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 	switch int(fieldType) {
 	case C.SQL_BIT:
 		var value C.BYTE
-		ret = C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_BIT, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_BIT, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
@@ -552,7 +868,9 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		}
 	case C.SQL_INTEGER, C.SQL_SMALLINT, C.SQL_TINYINT:
 		var value C.long
-		ret = C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_LONG, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_LONG, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
@@ -560,7 +878,9 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		}
 	case C.SQL_BIGINT:
 		var value C.longlong
-		ret = C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_SBIGINT, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_SBIGINT, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
@@ -568,7 +888,9 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		}
 	case C.SQL_FLOAT, C.SQL_REAL, C.SQL_DOUBLE:
 		var value C.double
-		ret = C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_DOUBLE, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(C.SQLHSTMT(stmt.handle), C.SQLUSMALLINT(fieldIndex+1), C.SQL_C_DOUBLE, C.SQLPOINTER(unsafe.Pointer(&value)), 0, &fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
@@ -576,13 +898,15 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		}
 	case C.SQL_NUMERIC:
 		var value = make([]byte, fl)
-		ret = C.SQLGetData(
-			C.SQLHSTMT(stmt.handle),
-			C.SQLUSMALLINT(fieldIndex+1),
-			C.SQL_C_NUMERIC,
-			C.SQLPOINTER(unsafe.Pointer(&value[0])),
-			fl,
-			&fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
+				C.SQLHSTMT(stmt.handle),
+				C.SQLUSMALLINT(fieldIndex+1),
+				C.SQL_C_NUMERIC,
+				C.SQLPOINTER(unsafe.Pointer(&value[0])),
+				fl,
+				&fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
@@ -590,23 +914,27 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		}
 	case C.SQL_CHAR, C.SQL_VARCHAR, C.SQL_LONGVARCHAR, C.SQL_WCHAR, C.SQL_WVARCHAR, C.SQL_WLONGVARCHAR:
 		value := make([]byte, int(fieldLen)+8)
-		ret = C.SQLGetData(
-			C.SQLHSTMT(stmt.handle),
-			C.SQLUSMALLINT(fieldIndex+1),
-			C.SQL_C_CHAR,
-			C.SQLPOINTER(unsafe.Pointer(&value[0])),
-			fieldLen+4,
-			&fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
+				C.SQLHSTMT(stmt.handle),
+				C.SQLUSMALLINT(fieldIndex+1),
+				C.SQL_C_CHAR,
+				C.SQLPOINTER(unsafe.Pointer(&value[0])),
+				fieldLen+4,
+				&fl)
+		})
 		v = value[:fl]
 	case C.SQL_TYPE_TIMESTAMP, C.SQL_TYPE_DATE, C.SQL_TYPE_TIME, C.SQL_DATETIME:
 		var value C.TIMESTAMP_STRUCT
-		ret = C.SQLGetData(
-			C.SQLHSTMT(stmt.handle),
-			C.SQLUSMALLINT(fieldIndex+1),
-			C.SQL_C_TYPE_TIMESTAMP,
-			C.SQLPOINTER(unsafe.Pointer(&value)),
-			C.SQLLEN(unsafe.Sizeof(value)),
-			&fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
+				C.SQLHSTMT(stmt.handle),
+				C.SQLUSMALLINT(fieldIndex+1),
+				C.SQL_C_TYPE_TIMESTAMP,
+				C.SQLPOINTER(unsafe.Pointer(&value)),
+				C.SQLLEN(unsafe.Sizeof(value)),
+				&fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
@@ -614,39 +942,45 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 		}
 	case C.SQL_BINARY, C.SQL_VARBINARY, C.SQL_LONGVARBINARY:
 		var vv int
-		ret = C.SQLGetData(
-			C.SQLHSTMT(stmt.handle),
-			C.SQLUSMALLINT(fieldIndex+1),
-			C.SQL_C_BINARY,
-			C.SQLPOINTER(unsafe.Pointer(&vv)),
-			0,
-			&fl)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
+				C.SQLHSTMT(stmt.handle),
+				C.SQLUSMALLINT(fieldIndex+1),
+				C.SQL_C_BINARY,
+				C.SQLPOINTER(unsafe.Pointer(&vv)),
+				0,
+				&fl)
+		})
 		if fl == -1 {
 			v = nil
 		} else {
 			value := make([]byte, fl)
-			ret = C.SQLGetData(
+			ret = stmt.wait(func() C.SQLRETURN {
+				return C.SQLGetData(
+					C.SQLHSTMT(stmt.handle),
+					C.SQLUSMALLINT(fieldIndex+1),
+					C.SQL_C_BINARY,
+					C.SQLPOINTER(unsafe.Pointer(&value[0])),
+					C.SQLLEN(fl),
+					&fl)
+			})
+			v = value
+		}
+	default:
+		value := make([]byte, fieldLen)
+		ret = stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
 				C.SQLHSTMT(stmt.handle),
 				C.SQLUSMALLINT(fieldIndex+1),
 				C.SQL_C_BINARY,
 				C.SQLPOINTER(unsafe.Pointer(&value[0])),
-				C.SQLLEN(fl),
+				fieldLen,
 				&fl)
-			v = value
-		}
-	default:
-		value := make([]byte, fieldLen)
-		ret = C.SQLGetData(
-			C.SQLHSTMT(stmt.handle),
-			C.SQLUSMALLINT(fieldIndex+1),
-			C.SQL_C_BINARY,
-			C.SQLPOINTER(unsafe.Pointer(&value[0])),
-			fieldLen,
-			&fl)
+		})
 		v = value
 	}
 	if !Success(ret) {
-		return v, int(fieldType), int(fl), FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+		return v, int(fieldType), int(fl), stmt.error()
 	}
 	return v, int(fieldType), int(fl), err
 }
@@ -654,8 +988,14 @@ func (stmt *Statement) GetField(fieldIndex int) (v interface{}, ftype int, flen
 func (stmt *Statement) NumFields() (int, error) {
 	var NOC C.SQLSMALLINT
 
-	if ret := C.SQLNumResultCols(C.SQLHSTMT(stmt.handle), &NOC); !Success(ret) {
-		return -1, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	if err := stmt.checkOpen("count fields"); err != nil {
+		return -1, err
+	}
+
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLNumResultCols(C.SQLHSTMT(stmt.handle), &NOC)
+	}); !Success(ret) {
+		return -1, stmt.error()
 	}
 	return int(NOC), nil
 }
@@ -668,14 +1008,20 @@ func (stmt *Statement) GetParamType(index int) (int, int, int, int, error) {
 		sizePtr  C.SQLULEN
 	)
 
-	if ret := C.SQLDescribeParam(
-		C.SQLHSTMT(stmt.handle),
-		C.SQLUSMALLINT(index),
-		&dataType,
-		&sizePtr,
-		&decPtr,
-		&nullPtr); !Success(ret) {
-		return -1, -1, -1, -1, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	if err := stmt.checkOpen("describe parameter"); err != nil {
+		return -1, -1, -1, -1, err
+	}
+
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLDescribeParam(
+			C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(index),
+			&dataType,
+			&sizePtr,
+			&decPtr,
+			&nullPtr)
+	}); !Success(ret) {
+		return -1, -1, -1, -1, stmt.error()
 	}
 	return int(dataType), int(sizePtr), int(decPtr), int(nullPtr), nil
 }
@@ -691,13 +1037,21 @@ func (stmt *Statement) BindParam(index int, param interface{}) error {
 		StrlenOrIndPt     C.SQLLEN
 	)
 
+	if err := stmt.checkOpen("bind parameter"); err != nil {
+		return err
+	}
+
 	v := reflect.ValueOf(param)
 	if param == nil {
-		ft, _, _, _, err := stmt.GetParamType(index)
-		if err != nil {
-			return err
+		if stmt.conn.supports(APIDescribeParam) {
+			ft, _, _, _, err := stmt.GetParamType(index)
+			if err != nil {
+				return err
+			}
+			ParameterType = C.SQLSMALLINT(ft)
+		} else {
+			Logger().Debug("godbc: SQLDescribeParam not supported, binding NULL as VARCHAR", "param", index)
 		}
-		ParameterType = C.SQLSMALLINT(ft)
 		if ParameterType == C.SQL_UNKNOWN_TYPE {
 			ParameterType = C.SQL_VARCHAR
 		}
@@ -769,20 +1123,62 @@ func (stmt *Statement) BindParam(index int, param interface{}) error {
 		ParameterValuePtr,
 		BufferLength,
 		&StrlenOrIndPt); !Success(ret) {
-		return FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+		return stmt.error()
 	}
 	return nil
 }
 
 func (stmt *Statement) NextResult() bool {
-	return C.SQLMoreResults(C.SQLHSTMT(stmt.handle)) != C.SQL_NO_DATA
+	ok, _ := stmt.NextResultContext(context.Background())
+	return ok
+}
+
+// NextResultContext moves to the next result, a result set or the row
+// count of an UPDATE, INSERT or DELETE, and reports whether there is one.
+// Like FetchContext, it gives up when ctx is done provided the statement
+// is asynchronous.
+func (stmt *Statement) NextResultContext(ctx context.Context) (bool, error) {
+	if err := stmt.checkResults("move to the next result"); err != nil {
+		return false, err
+	}
+	if !stmt.conn.supports(APIMoreResults) {
+		Logger().Debug("godbc: SQLMoreResults not supported, assuming a single result set")
+		return false, nil
+	}
+	stmt.fetched = 0
+	ret, err := stmt.poll(ctx, func() C.SQLRETURN {
+		return C.SQLMoreResults(C.SQLHSTMT(stmt.handle))
+	})
+	if err != nil {
+		return false, contextError(ctx, err, false)
+	}
+	if ret == C.SQL_NO_DATA {
+		// No more results closes the cursor.
+		stmt.cursorOpen = false
+		return false, nil
+	}
+	stmt.cursorOpen = stmt.hasResultSet()
+	return true, nil
 }
 
+// NumRows returns the number of rows of the result set. When the driver
+// cannot tell, it is the number of rows fetched so far.
 func (stmt *Statement) NumRows() (int, error) {
 	var NOR C.SQLLEN
 
+	if err := stmt.checkOpen("count rows"); err != nil {
+		return -1, err
+	}
+
+	if !stmt.conn.supports(APIRowCount) {
+		Logger().Debug("godbc: SQLRowCount not supported, counting fetched rows")
+		return stmt.fetched, nil
+	}
 	if ret := C.SQLRowCount(C.SQLHSTMT(stmt.handle), &NOR); !Success(ret) {
-		return -1, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+		return -1, stmt.error()
+	}
+	if NOR < 0 {
+		return stmt.fetched, nil
 	}
 	return int(NOR), nil
 }
@@ -809,17 +1205,24 @@ func (stmt *Statement) FieldMetadata(col int) (*Field, error) {
 		DecimalDigits C.SQLSMALLINT
 		Nullable      C.SQLSMALLINT
 	)
+
+	if err := stmt.checkOpen("describe column"); err != nil {
+		return nil, err
+	}
+
 	ColumnName := make([]byte, infoBufferLen)
-	if ret := C.SQLDescribeCol(C.SQLHSTMT(stmt.handle),
-		C.SQLUSMALLINT(col),
-		(*C.SQLCHAR)(unsafe.Pointer(&ColumnName[0])),
-		BufferLength,
-		&NameLength,
-		&DataType,
-		&ColumnSize,
-		&DecimalDigits,
-		&Nullable); !Success(ret) {
-		return nil, FormatError(C.SQL_HANDLE_STMT, stmt.handle)
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLDescribeCol(C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(col),
+			(*C.SQLCHAR)(unsafe.Pointer(&ColumnName[0])),
+			BufferLength,
+			&NameLength,
+			&DataType,
+			&ColumnSize,
+			&DecimalDigits,
+			&Nullable)
+	}); !Success(ret) {
+		return nil, stmt.error()
 	}
 	return &Field{
 		Name:          string(ColumnName[0:NameLength]),
@@ -834,8 +1237,18 @@ func (stmt *Statement) free() {
 	C.SQLFreeHandle(C.SQL_HANDLE_STMT, stmt.handle)
 }
 
+// Close frees the statement handle. It is the only way out of the
+// statement's lifecycle, and a no-op when already done.
 func (stmt *Statement) Close() {
+	if stmt.closed {
+		return
+	}
 	stmt.free()
+	stmt.closed, stmt.cursorOpen = true, false
+	if stmt.bookmark != nil {
+		C.free(stmt.bookmark)
+		stmt.bookmark = nil
+	}
 }
 
 func Success(ret C.SQLRETURN) bool {
@@ -844,33 +1257,28 @@ func Success(ret C.SQLRETURN) bool {
 
 func FormatError(ht C.SQLSMALLINT, h C.SQLHANDLE) error {
 	var (
-		nativeError C.SQLINTEGER
-		textLength  C.SQLSMALLINT
-	)
-	var (
-		sqlState    = make([]uint16, 6)
-		messageText = make([]uint16, C.SQL_MAX_MESSAGE_LENGTH)
-		err         = &Error{}
+		number C.SQLINTEGER
+		code   C.SQLINTEGER
+		err    = &Error{}
 	)
 
-	for i := 0; ; i++ {
-		if ret := C.SQLGetDiagRecW(
-			C.SQLSMALLINT(ht),
-			h,
-			C.SQLSMALLINT(i+1),
-			(*C.SQLWCHAR)(unsafe.Pointer(&sqlState[0])),
-			&nativeError,
-			(*C.SQLWCHAR)(unsafe.Pointer(&messageText[0])),
-			C.SQL_MAX_MESSAGE_LENGTH,
-			&textLength); ret == C.SQL_INVALID_HANDLE || ret == C.SQL_NO_DATA {
-			break
+	if diagField(ht, h, 0, C.SQL_DIAG_NUMBER, unsafe.Pointer(&number), C.SQL_IS_INTEGER) {
+		err.Number = int(number)
+	}
+	if ht == C.SQL_HANDLE_STMT {
+		err.DynamicFunction = diagString(ht, h, 0, C.SQL_DIAG_DYNAMIC_FUNCTION)
+		if diagField(ht, h, 0, C.SQL_DIAG_DYNAMIC_FUNCTION_CODE, unsafe.Pointer(&code), C.SQL_IS_INTEGER) {
+			err.DynamicFunctionCode = int(code)
 		}
+	}
+
+	err.Diagnostics = diagnostics(ht, h)
+	for i, d := range err.Diagnostics {
 		if i == 0 { // first error message save the SQLSTATE.
-			err.SQLState = UTF16ToString(sqlState)
-			err.NativeError = int(nativeError)
+			err.SQLState = d.SQLState
+			err.NativeError = d.NativeError
 		}
-		err.ErrorMessage += UTF16ToString(messageText)
+		err.ErrorMessage += d.Message
 	}
-
 	return err
 }
@@ -0,0 +1,219 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package odbcinst wraps the ODBC installer API to register drivers and
+// data sources programmatically. It lives apart from godbc so that only
+// programs importing it link against the installer library.
+package odbcinst
+
+/*
+#cgo darwin LDFLAGS: -lodbcinst
+#cgo freebsd LDFLAGS: -lodbcinst
+#cgo linux LDFLAGS: -lodbcinst
+#cgo windows LDFLAGS: -lodbccp32
+
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windows.h>
+#endif
+
+#include <odbcinst.h>
+*/
+import "C"
+import (
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+const (
+	pathBufferLen    = 4096
+	messageBufferLen = 1024
+)
+
+// Action is a data source operation for ConfigDataSource.
+type Action int
+
+// Data source actions.
+const (
+	AddDSN          Action = C.ODBC_ADD_DSN
+	ConfigDSN       Action = C.ODBC_CONFIG_DSN
+	RemoveDSN       Action = C.ODBC_REMOVE_DSN
+	AddSystemDSN    Action = C.ODBC_ADD_SYS_DSN
+	ConfigSystemDSN Action = C.ODBC_CONFIG_SYS_DSN
+	RemoveSystemDSN Action = C.ODBC_REMOVE_SYS_DSN
+)
+
+// Error is an error reported by SQLInstallerError.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e != nil {
+		return "odbcinst: " + e.Message
+	}
+	return ""
+}
+
+// ConfigDataSource adds, modifies or removes a data source of the given
+// driver, described by its description as listed by godbc.Drivers. attrs
+// holds the data source keywords, including DSN.
+func ConfigDataSource(action Action, driver string, attrs map[string]string) error {
+	cdriver := C.CString(driver)
+	defer C.free(unsafe.Pointer(cdriver))
+	cattrs := C.CString(attrList(attrs))
+	defer C.free(unsafe.Pointer(cattrs))
+
+	if ok := C.SQLConfigDataSource(nil, C.WORD(action), cdriver, cattrs); ok == 0 {
+		return installerError()
+	}
+	return nil
+}
+
+// InstallDriver registers the driver named description with the given
+// attributes, such as Driver and Setup, in the directory path (empty for
+// the default one). It returns the directory the driver was installed in.
+func InstallDriver(description string, attrs map[string]string, path string) (string, error) {
+	var (
+		pathOut    = make([]byte, pathBufferLen)
+		pathLen    C.WORD
+		usageCount C.DWORD
+		cpath      *C.char
+	)
+
+	cdriver := C.CString(description + "\x00" + attrList(attrs))
+	defer C.free(unsafe.Pointer(cdriver))
+	if path != "" {
+		cpath = C.CString(path)
+		defer C.free(unsafe.Pointer(cpath))
+	}
+
+	if ok := C.SQLInstallDriverEx(
+		cdriver,
+		cpath,
+		(*C.char)(unsafe.Pointer(&pathOut[0])),
+		C.WORD(len(pathOut)),
+		&pathLen,
+		C.ODBC_INSTALL_COMPLETE,
+		&usageCount); ok == 0 {
+		return "", installerError()
+	}
+	if int(pathLen) >= len(pathOut) {
+		pathLen = C.WORD(len(pathOut) - 1)
+	}
+	return string(pathOut[:pathLen]), nil
+}
+
+// RemoveDriver decrements the usage count of the driver named description
+// and removes it when the count drops to zero. If removeDSNs is true the
+// data sources using the driver are removed as well. It returns the new
+// usage count.
+func RemoveDriver(description string, removeDSNs bool) (int, error) {
+	var (
+		usageCount C.DWORD
+		remove     C.BOOL
+	)
+
+	cdriver := C.CString(description)
+	defer C.free(unsafe.Pointer(cdriver))
+	if removeDSNs {
+		remove = 1
+	}
+
+	if ok := C.SQLRemoveDriver(cdriver, remove, &usageCount); ok == 0 {
+		return 0, installerError()
+	}
+	return int(usageCount), nil
+}
+
+// GetPrivateProfileString reads entry from section of the installer
+// configuration file filename (e.g. "odbc.ini" or "odbcinst.ini"),
+// returning def if it is not set.
+func GetPrivateProfileString(section, entry, def, filename string) (string, error) {
+	csection := C.CString(section)
+	defer C.free(unsafe.Pointer(csection))
+	centry := C.CString(entry)
+	defer C.free(unsafe.Pointer(centry))
+	cdef := C.CString(def)
+	defer C.free(unsafe.Pointer(cdef))
+	cfile := C.CString(filename)
+	defer C.free(unsafe.Pointer(cfile))
+
+	for size := pathBufferLen; ; size *= 2 {
+		buf := make([]byte, size)
+		n := int(C.SQLGetPrivateProfileString(
+			csection,
+			centry,
+			cdef,
+			(*C.char)(unsafe.Pointer(&buf[0])),
+			C.int(len(buf)),
+			cfile))
+		if n < 0 {
+			return "", installerError()
+		}
+		// A full buffer may mean the value was truncated.
+		if n < size-1 {
+			return string(buf[:n]), nil
+		}
+	}
+}
+
+// attrList formats attrs as a "key=value\0key=value\0\0" list, with the
+// DSN keyword first as some setup libraries expect.
+func attrList(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if dsn := strings.EqualFold(keys[i], "DSN"); dsn || strings.EqualFold(keys[j], "DSN") {
+			return dsn
+		}
+		return keys[i] < keys[j]
+	})
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + attrs[k] + "\x00")
+	}
+	b.WriteString("\x00")
+	return b.String()
+}
+
+// installerError collects the errors reported by the last installer call.
+func installerError() error {
+	var (
+		code    C.DWORD
+		msgLen  C.WORD
+		message = make([]byte, messageBufferLen)
+		err     *Error
+	)
+
+	for i := 1; i <= 8; i++ {
+		ret := C.SQLInstallerError(
+			C.WORD(i),
+			&code,
+			(*C.char)(unsafe.Pointer(&message[0])),
+			C.WORD(len(message)),
+			&msgLen)
+		if ret != C.SQL_SUCCESS && ret != C.SQL_SUCCESS_WITH_INFO {
+			break
+		}
+		if int(msgLen) >= len(message) {
+			msgLen = C.WORD(len(message) - 1)
+		}
+		if err == nil {
+			err = &Error{Code: int(code), Message: string(message[:msgLen])}
+		} else {
+			err.Message += "; " + string(message[:msgLen])
+		}
+	}
+	if err == nil {
+		return &Error{Code: C.ODBC_ERROR_GENERAL_ERR, Message: "unknown installer error"}
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package odbcinst
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttrList(t *testing.T) {
+	tests := []struct {
+		attrs map[string]string
+		want  string
+	}{
+		{nil, "\x00"},
+		{map[string]string{"Server": "db"}, "Server=db\x00\x00"},
+		{
+			map[string]string{"Server": "db", "Database": "test", "DSN": "x"},
+			"DSN=x\x00Database=test\x00Server=db\x00\x00",
+		},
+		{
+			map[string]string{"A": "1", "dsn": "x", "Z": "2"},
+			"dsn=x\x00A=1\x00Z=2\x00\x00",
+		},
+	}
+	for _, tt := range tests {
+		if got := attrList(tt.attrs); got != tt.want {
+			t.Errorf("attrList(%v) = %q, want %q", tt.attrs, got, tt.want)
+		}
+	}
+}
+
+func TestInstallerErrorWithoutRecords(t *testing.T) {
+	err := installerError()
+	var e *Error
+	if !errors.As(err, &e) || e.Message != "unknown installer error" {
+		t.Errorf("installerError() = %v, want the unknown installer error", err)
+	}
+	if got, want := err.Error(), "odbcinst: unknown installer error"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+// TestInstallRemoveDriver registers a driver in a unixODBC configuration
+// written to a temporary directory and removes it. As for godbc's
+// TestDriversAndDataSources, the test runs again in a child process
+// started with ODBCSYSINI pointing there.
+func TestInstallRemoveDriver(t *testing.T) {
+	if os.Getenv("ODBCINST_TEST_INI") == "" {
+		if _, err := exec.LookPath("odbcinst"); err != nil {
+			t.Skip("unixODBC not available")
+		}
+		dir := t.TempDir()
+		for _, name := range []string{"odbcinst.ini", "odbc.ini", "user.ini"} {
+			if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=^TestInstallRemoveDriver$")
+		cmd.Env = append(os.Environ(),
+			"ODBCINST_TEST_INI=1",
+			"ODBCSYSINI="+dir,
+			"ODBCINSTINI=odbcinst.ini",
+			"ODBCINI="+filepath.Join(dir, "user.ini"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		return
+	}
+
+	const driver = "Test Driver"
+	if _, err := InstallDriver(driver, map[string]string{
+		"Driver":      "/nonexistent/libtestdriver.so",
+		"Description": "Driver for tests",
+	}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := GetPrivateProfileString(driver, "Driver", "", "odbcinst.ini"); err != nil || got != "/nonexistent/libtestdriver.so" {
+		t.Errorf("installed Driver = %q, %v, want /nonexistent/libtestdriver.so", got, err)
+	}
+
+	n, err := RemoveDriver(driver, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("usage count after removal = %d, want 0", n)
+	}
+	if got, err := GetPrivateProfileString(driver, "Driver", "gone", "odbcinst.ini"); err != nil || got != "gone" {
+		t.Errorf("removed Driver = %q, %v, want the default", got, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ErrCommitOutcomeUnknown reports that the connection was lost while
+// committing: the transaction may or may not have been committed, so it is
+// never retried.
+var ErrCommitOutcomeUnknown = errors.New("godbc: commit outcome unknown")
+
+// TxOptions configures RunInTx. The zero value is usable.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	MaxAttempts int           // attempts in all, 3 if zero.
+	BaseDelay   time.Duration // delay before the first retry, 10ms if zero.
+	MaxDelay    time.Duration // bound of the doubling delay, 1s if zero.
+
+	// OnRetry, if set, is called before each retry with the number of the
+	// failed attempt, its error and the delay before the next one.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// RunInTx runs fn in a transaction on db, a *sql.DB, a *sql.Conn or a
+// *Connection, and commits it if fn returns nil. fn receives the
+// transaction: a *sql.Tx, or the *Connection itself.
+//
+// When fn or the commit fail with ErrSerializationFailure (deadlocks
+// included) the transaction is rolled back and the whole of fn is run
+// again after a jittered exponential delay, until opts.MaxAttempts is
+// reached. A connection lost while committing, or a commit the driver
+// could not complete for sure (SQLSTATE 40003), yields an error matching
+// ErrCommitOutcomeUnknown instead, which is never retried.
+func RunInTx(ctx context.Context, db interface{}, opts *TxOptions, fn func(tx interface{}) error) error {
+	var o TxOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = 10 * time.Millisecond
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = time.Second
+	}
+
+	var run func() error
+	switch db := db.(type) {
+	case *sql.DB:
+		run = func() error { return runSQLTx(ctx, db, &o, fn) }
+	case *sql.Conn:
+		run = func() error { return runSQLTx(ctx, db, &o, fn) }
+	case *Connection:
+		run = func() error { return runConnTx(db, &o, fn) }
+	default:
+		return fmt.Errorf("godbc: RunInTx: unsupported database type %T", db)
+	}
+
+	delay := o.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt >= o.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		// Full jitter over the upper half keeps concurrent retries apart.
+		d := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		if o.OnRetry != nil {
+			o.OnRetry(attempt, err, d)
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+		delay = min(2*delay, o.MaxDelay)
+	}
+}
+
+func retryable(err error) bool {
+	return !errors.Is(err, ErrCommitOutcomeUnknown) && errors.Is(err, ErrSerializationFailure)
+}
+
+// commitError marks a lost connection during commit, and a commit the
+// driver could not tell the completion of (SQLSTATE 40003).
+func commitError(err error) error {
+	var e *Error
+	if errors.Is(err, ErrConnectionLost) || errors.As(err, &e) && e.SQLState == "40003" {
+		return fmt.Errorf("%w: %w", ErrCommitOutcomeUnknown, err)
+	}
+	return err
+}
+
+type txBeginner interface {
+	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+}
+
+func runSQLTx(ctx context.Context, db txBeginner, o *TxOptions, fn func(interface{}) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly})
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return commitError(tx.Commit())
+}
+
+func runConnTx(conn *Connection, o *TxOptions, fn func(interface{}) error) error {
+	isolation, err := TxnIsolationOf(o.Isolation)
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(isolation, o.ReadOnly)
+	if err != nil {
+		return err
+	}
+	if err := fn(conn); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		if !tx.done {
+			tx.Rollback()
+		}
+		return commitError(err)
+	}
+	return nil
+}
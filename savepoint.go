@@ -0,0 +1,127 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSavepointsUnsupported reports a DBMS without savepoints.
+var ErrSavepointsUnsupported = errors.New("godbc: savepoints are not supported by the DBMS")
+
+// savepointSyntax holds the statements for a DBMS, "" meaning the
+// operation is implicit.
+type savepointSyntax struct {
+	save, rollback, release string
+}
+
+var savepointSyntaxes = map[dbmsKind]savepointSyntax{
+	dbmsSQLServer: {"SAVE TRANSACTION %s", "ROLLBACK TRANSACTION %s", ""},
+	dbmsOracle:    {"SAVEPOINT %s", "ROLLBACK TO SAVEPOINT %s", ""},
+	dbmsDB2:       {"SAVEPOINT %s ON ROLLBACK RETAIN CURSORS", "ROLLBACK TO SAVEPOINT %s", "RELEASE SAVEPOINT %s"},
+}
+
+// standardSavepoints is the SQL standard syntax, used by the others.
+var standardSavepoints = savepointSyntax{"SAVEPOINT %s", "ROLLBACK TO SAVEPOINT %s", "RELEASE SAVEPOINT %s"}
+
+// Savepoint sets a savepoint called name in the current transaction, see
+// BeginTx.
+func (conn *Connection) Savepoint(name string) error {
+	return conn.savepoint(conn.savepointSyntax().save, name)
+}
+
+// RollbackTo rolls the current transaction back to the savepoint name,
+// which stays set.
+func (conn *Connection) RollbackTo(name string) error {
+	return conn.savepoint(conn.savepointSyntax().rollback, name)
+}
+
+// ReleaseSavepoint removes the savepoint name. It is a no-op on DBMS that
+// have no such statement, SQL Server and Oracle.
+func (conn *Connection) ReleaseSavepoint(name string) error {
+	return conn.savepoint(conn.savepointSyntax().release, name)
+}
+
+func (conn *Connection) savepointSyntax() savepointSyntax {
+	if s, ok := savepointSyntaxes[conn.dbms]; ok {
+		return s
+	}
+	return standardSavepoints
+}
+
+func (conn *Connection) savepoint(format, name string) error {
+	if name == "" {
+		return errors.New("godbc: empty savepoint name")
+	}
+	if format == "" {
+		return nil
+	}
+	// Without transactions there is nothing to set a savepoint in.
+	if caps, err := conn.Capabilities(); err == nil && caps.TxnCapable == C.SQL_TC_NONE {
+		return ErrSavepointsUnsupported
+	}
+	if autocommit, err := conn.GetAttrInt(AttrAutocommit); err == nil && autocommit != 0 {
+		return errors.New("godbc: savepoints need a transaction, autocommit is on")
+	}
+	ident, err := conn.quoteIdentifier(name)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := conn.ExecDirect(fmt.Sprintf(format, ident))
+	if err != nil {
+		if savepointsUnsupported(conn.dbms, err) {
+			return fmt.Errorf("%w: %w", ErrSavepointsUnsupported, err)
+		}
+		return err
+	}
+	stmt.Close()
+	return nil
+}
+
+// savepointsUnsupported reports whether err, returned by a savepoint
+// statement, tells the driver or DBMS lacks savepoints: HYC00 or IM001
+// from the driver, or a syntax error from a DBMS whose syntax is unknown.
+func savepointsUnsupported(dbms dbmsKind, err error) bool {
+	if e, ok := err.(*Error); ok && (e.SQLState == "HYC00" || e.SQLState == "IM001") {
+		return true
+	}
+	return dbms == dbmsUnknown && errors.Is(err, ErrSyntax)
+}
+
+// quoteIdentifier quotes name with the DBMS identifier quote character.
+func (conn *Connection) quoteIdentifier(name string) (string, error) {
+	caps, err := conn.Capabilities()
+	if err != nil {
+		return "", err
+	}
+	q := caps.IdentifierQuoteChar
+	if q == "" || q == " " {
+		// Quoting is not supported, only plain identifiers are safe.
+		for _, r := range name {
+			if r != '_' && (r < '0' || r > '9') && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+				return "", fmt.Errorf("godbc: identifier %q cannot be quoted", name)
+			}
+		}
+		return name, nil
+	}
+	return q + strings.ReplaceAll(name, q, q+q) + q, nil
+}
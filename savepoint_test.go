@@ -0,0 +1,27 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import "testing"
+
+func TestSavepointsUnsupported(t *testing.T) {
+	tests := []struct {
+		dbms  dbmsKind
+		state string
+		want  bool
+	}{
+		{dbmsUnknown, "HYC00", true},
+		{dbmsPostgreSQL, "IM001", true},
+		{dbmsUnknown, "42000", true},
+		{dbmsPostgreSQL, "42000", false},
+		{dbmsUnknown, "08S01", false},
+	}
+	for _, tt := range tests {
+		err := &Error{SQLState: tt.state, dbms: tt.dbms}
+		if got := savepointsUnsupported(tt.dbms, err); got != tt.want {
+			t.Errorf("savepointsUnsupported(%v, %s) = %v, want %v", tt.dbms, tt.state, got, tt.want)
+		}
+	}
+}
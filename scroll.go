@@ -0,0 +1,197 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"unsafe"
+)
+
+// CursorType is the kind of cursor a statement opens, see SetCursorType.
+// It may also be passed to Prepare.
+type CursorType int
+
+// Cursor types.
+const (
+	CursorForwardOnly CursorType = C.SQL_CURSOR_FORWARD_ONLY
+	CursorStatic      CursorType = C.SQL_CURSOR_STATIC
+	CursorKeyset      CursorType = C.SQL_CURSOR_KEYSET_DRIVEN
+	CursorDynamic     CursorType = C.SQL_CURSOR_DYNAMIC
+)
+
+var errNotScrollable = errors.New("godbc: statement cursor is not scrollable")
+
+// SetCursorType sets the cursor type. Every type but CursorForwardOnly
+// makes the cursor scrollable. It must be set before the statement is
+// executed; the driver may substitute a type it supports, see CursorType.
+func (stmt *Statement) SetCursorType(t CursorType) error {
+	if err := stmt.setAttr(C.SQL_ATTR_CURSOR_TYPE, uintptr(t)); err != nil {
+		return err
+	}
+	stmt.scrollable = t != CursorForwardOnly
+	return nil
+}
+
+// CursorType returns the cursor type in use.
+func (stmt *Statement) CursorType() (CursorType, error) {
+	n, err := stmt.getAttr(C.SQL_ATTR_CURSOR_TYPE)
+	return CursorType(n), err
+}
+
+// SetScrollable asks for a scrollable cursor and lets the driver choose its
+// type.
+func (stmt *Statement) SetScrollable(b bool) error {
+	var n uintptr = C.SQL_NONSCROLLABLE
+
+	if b {
+		n = C.SQL_SCROLLABLE
+	}
+	if err := stmt.setAttr(C.SQL_ATTR_CURSOR_SCROLLABLE, n); err != nil {
+		return err
+	}
+	stmt.scrollable = b
+	return nil
+}
+
+// Scrollable reports whether the statement was set up for a scrollable
+// cursor.
+func (stmt *Statement) Scrollable() bool {
+	return stmt.scrollable
+}
+
+// SetUseBookmarks turns on variable-length bookmarks, needed by Bookmark
+// and FetchBookmark. It must be set before the statement is executed.
+func (stmt *Statement) SetUseBookmarks(b bool) error {
+	var n uintptr = C.SQL_UB_OFF
+
+	if b {
+		n = C.SQL_UB_VARIABLE
+	}
+	return stmt.setAttr(C.SQL_ATTR_USE_BOOKMARKS, n)
+}
+
+// FetchFirst moves to the first row of the result set.
+func (stmt *Statement) FetchFirst() (bool, error) {
+	return stmt.fetchScroll(C.SQL_FETCH_FIRST, 0)
+}
+
+// FetchLast moves to the last row of the result set.
+func (stmt *Statement) FetchLast() (bool, error) {
+	return stmt.fetchScroll(C.SQL_FETCH_LAST, 0)
+}
+
+// FetchPrior moves to the previous row.
+func (stmt *Statement) FetchPrior() (bool, error) {
+	return stmt.fetchScroll(C.SQL_FETCH_PRIOR, 0)
+}
+
+// FetchAbsolute moves to row n, counting from 1. A negative n counts from
+// the end of the result set, -1 being the last row.
+func (stmt *Statement) FetchAbsolute(n int) (bool, error) {
+	return stmt.fetchScroll(C.SQL_FETCH_ABSOLUTE, n)
+}
+
+// FetchRelative moves n rows forward, or backward when n is negative.
+func (stmt *Statement) FetchRelative(n int) (bool, error) {
+	return stmt.fetchScroll(C.SQL_FETCH_RELATIVE, n)
+}
+
+// Bookmark returns the bookmark of the current row, see SetUseBookmarks.
+// It is nil if the row has none.
+func (stmt *Statement) Bookmark() ([]byte, error) {
+	var (
+		n   C.SQLLEN
+		buf = make([]byte, 64)
+		out []byte
+	)
+
+	if err := stmt.checkOpen("get bookmark"); err != nil {
+		return nil, err
+	}
+
+	for {
+		ret := stmt.wait(func() C.SQLRETURN {
+			return C.SQLGetData(
+				C.SQLHSTMT(stmt.handle),
+				0,
+				C.SQL_C_VARBOOKMARK,
+				C.SQLPOINTER(unsafe.Pointer(&buf[0])),
+				C.SQLLEN(len(buf)),
+				&n)
+		})
+		if ret == C.SQL_NO_DATA && out != nil {
+			return out, nil
+		}
+		if !Success(ret) {
+			return nil, stmt.error()
+		}
+		switch {
+		case n == C.SQL_NULL_DATA:
+			return nil, nil
+		case n == C.SQL_NO_TOTAL:
+			return nil, errors.New("godbc: driver did not report the bookmark length")
+		case int(n) <= len(buf):
+			return append(out, buf[:n]...), nil
+		}
+		// 01004: n bytes were left, the buffer holds the first ones and
+		// the next call returns the rest.
+		out = append(out, buf...)
+		if rest := int(n) - len(buf); rest > len(buf) {
+			buf = make([]byte, rest)
+		}
+	}
+}
+
+// FetchBookmark moves to the row offset rows away from the one bookmark
+// identifies.
+func (stmt *Statement) FetchBookmark(bookmark []byte, offset int) (bool, error) {
+	if len(bookmark) == 0 {
+		return false, errors.New("godbc: empty bookmark")
+	}
+	// The driver reads the bookmark through SQL_ATTR_FETCH_BOOKMARK_PTR,
+	// which must stay valid until the next call: keep it in C memory.
+	bm := C.malloc(C.size_t(len(bookmark)))
+	copy(unsafe.Slice((*byte)(bm), len(bookmark)), bookmark)
+	if err := stmt.setAttr(C.SQL_ATTR_FETCH_BOOKMARK_PTR, uintptr(bm)); err != nil {
+		C.free(bm)
+		return false, err
+	}
+	if stmt.bookmark != nil {
+		C.free(stmt.bookmark)
+	}
+	stmt.bookmark = bm
+	return stmt.fetchScroll(C.SQL_FETCH_BOOKMARK, offset)
+}
+
+func (stmt *Statement) fetchScroll(orientation C.SQLSMALLINT, offset int) (bool, error) {
+	if err := stmt.checkCursor("fetch"); err != nil {
+		return false, err
+	}
+	if !stmt.scrollable {
+		return false, errNotScrollable
+	}
+	ret, err := stmt.poll(context.Background(), func() C.SQLRETURN {
+		return C.SQLFetchScroll(C.SQLHSTMT(stmt.handle), orientation, C.SQLLEN(offset))
+	})
+	if err != nil {
+		return false, err
+	}
+	return ret != C.SQL_NO_DATA, nil
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// Concurrency is the concurrency control of a cursor, see SetConcurrency.
+// It may also be passed to Prepare.
+type Concurrency int
+
+// Concurrency controls.
+const (
+	ConcurReadOnly Concurrency = C.SQL_CONCUR_READ_ONLY
+	ConcurLock     Concurrency = C.SQL_CONCUR_LOCK
+	ConcurRowVer   Concurrency = C.SQL_CONCUR_ROWVER
+	ConcurValues   Concurrency = C.SQL_CONCUR_VALUES
+)
+
+// ErrPositionedUnsupported reports a driver without the functions behind
+// UpdateCurrent, DeleteCurrent, RefreshCurrent or BulkAdd.
+var ErrPositionedUnsupported = errors.New("godbc: positioned operations are not supported by the driver")
+
+// Ignore, given as a column value to UpdateCurrent or BulkAdd, leaves the
+// column out: it keeps its value or gets its default.
+var Ignore = &ignoreValue{}
+
+type ignoreValue struct{ _ byte }
+
+// SetConcurrency sets the concurrency control of the cursor. Updates
+// through UpdateCurrent and DeleteCurrent need anything but
+// ConcurReadOnly. It must be set before the statement is executed.
+func (stmt *Statement) SetConcurrency(c Concurrency) error {
+	return stmt.setAttr(C.SQL_ATTR_CONCURRENCY, uintptr(c))
+}
+
+// Concurrency returns the concurrency control of the cursor.
+func (stmt *Statement) Concurrency() (Concurrency, error) {
+	n, err := stmt.getAttr(C.SQL_ATTR_CONCURRENCY)
+	return Concurrency(n), err
+}
+
+// SetCursorName names the statement's cursor, for use in
+// "UPDATE ... WHERE CURRENT OF name" statements on the same connection.
+func (stmt *Statement) SetCursorName(name string) error {
+	if err := stmt.checkOpen("set cursor name"); err != nil {
+		return err
+	}
+
+	if ret := stmt.conn.callW(C.SQL_HANDLE_STMT, stmt.handle, func() C.SQLRETURN {
+		return C.SQLSetCursorNameW(
+			C.SQLHSTMT(stmt.handle),
+			(*C.SQLWCHAR)(unsafe.Pointer(StringToUTF16Ptr(name))),
+			C.SQL_NTS)
+	}, func() C.SQLRETURN {
+		return C.SQLSetCursorName(
+			C.SQLHSTMT(stmt.handle),
+			(*C.SQLCHAR)(unsafe.Pointer(&[]byte(name + "\x00")[0])),
+			C.SQL_NTS)
+	}); !Success(ret) {
+		return stmt.error()
+	}
+	return nil
+}
+
+// CursorName returns the name of the statement's cursor, generated by the
+// driver unless set by SetCursorName.
+func (stmt *Statement) CursorName() (string, error) {
+	var (
+		n    C.SQLSMALLINT
+		buf  = make([]uint16, 64)
+		ansi []byte
+	)
+
+	if err := stmt.checkOpen("get cursor name"); err != nil {
+		return "", err
+	}
+
+	for {
+		if ret := stmt.conn.callW(C.SQL_HANDLE_STMT, stmt.handle, func() C.SQLRETURN {
+			return C.SQLGetCursorNameW(
+				C.SQLHSTMT(stmt.handle),
+				(*C.SQLWCHAR)(unsafe.Pointer(&buf[0])),
+				C.SQLSMALLINT(len(buf)),
+				&n)
+		}, func() C.SQLRETURN {
+			ansi = make([]byte, len(buf))
+			return C.SQLGetCursorName(
+				C.SQLHSTMT(stmt.handle),
+				(*C.SQLCHAR)(unsafe.Pointer(&ansi[0])),
+				C.SQLSMALLINT(len(ansi)),
+				&n)
+		}); !Success(ret) {
+			return "", stmt.error()
+		}
+		if ansi != nil && int(n) < len(ansi) {
+			return string(ansi[:n]), nil
+		}
+		if ansi == nil && int(n) < len(buf) {
+			return UTF16ToString(buf[:n]), nil
+		}
+		buf = make([]uint16, int(n)+1)
+	}
+}
+
+// UpdateCurrent updates the row under the cursor with values, one per
+// result column in order. A nil value sets the column to NULL, Ignore
+// leaves it unchanged.
+func (stmt *Statement) UpdateCurrent(values ...interface{}) error {
+	return stmt.setPos("update current row", C.SQL_UPDATE, values)
+}
+
+// DeleteCurrent deletes the row under the cursor.
+func (stmt *Statement) DeleteCurrent() error {
+	return stmt.setPos("delete current row", C.SQL_DELETE, nil)
+}
+
+// RefreshCurrent reads the row under the cursor again from the data
+// source.
+func (stmt *Statement) RefreshCurrent() error {
+	return stmt.setPos("refresh current row", C.SQL_REFRESH, nil)
+}
+
+// BulkAdd inserts rows through the cursor of a query, each holding a value
+// per result column as for UpdateCurrent.
+func (stmt *Statement) BulkAdd(rows [][]interface{}) error {
+	if err := stmt.checkPositioned("bulk add", APIBulkOperations); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		b, err := stmt.bindRow(row)
+		if err != nil {
+			return err
+		}
+		ret := stmt.wait(func() C.SQLRETURN {
+			return C.SQLBulkOperations(C.SQLHSTMT(stmt.handle), C.SQL_ADD)
+		})
+		if !Success(ret) {
+			err = stmt.error()
+		}
+		stmt.unbind(b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stmt *Statement) setPos(name string, op C.SQLUSMALLINT, values []interface{}) error {
+	var b []unsafe.Pointer
+
+	if err := stmt.checkPositioned(name, APISetPos); err != nil {
+		return err
+	}
+	if values != nil {
+		var err error
+		if b, err = stmt.bindRow(values); err != nil {
+			return err
+		}
+		defer stmt.unbind(b)
+	}
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C.SQLSetPos(C.SQLHSTMT(stmt.handle), 1, op, C.SQL_LOCK_NO_CHANGE)
+	}); !Success(ret) {
+		return stmt.error()
+	}
+	return nil
+}
+
+// checkPositioned fails when there is no cursor for the positioned
+// operation op, or when the driver lacks api, the function running it.
+func (stmt *Statement) checkPositioned(op string, api int) error {
+	if err := stmt.checkCursor(op); err != nil {
+		return err
+	}
+	if !stmt.conn.supports(api) {
+		return fmt.Errorf("%w: %s", ErrPositionedUnsupported, op)
+	}
+	return nil
+}
+
+// bindRow binds values to the result columns for SQLSetPos and
+// SQLBulkOperations, which read them when called. The buffers are C memory
+// to be released by unbind.
+func (stmt *Statement) bindRow(values []interface{}) ([]unsafe.Pointer, error) {
+	var bufs []unsafe.Pointer
+
+	n, err := stmt.NumFields()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != n {
+		return nil, fmt.Errorf("godbc: got %d values for %d columns", len(values), n)
+	}
+
+	for i, v := range values {
+		if v == Ignore {
+			continue
+		}
+		var (
+			ctype C.SQLSMALLINT = C.SQL_C_DEFAULT
+			data  []byte
+			ind   = C.SQLLEN(C.SQL_NULL_DATA)
+		)
+		if v != nil {
+			if ctype, data, err = cValue(v); err != nil {
+				stmt.unbind(bufs)
+				return nil, fmt.Errorf("godbc: column %d: %v", i+1, err)
+			}
+			ind = C.SQLLEN(len(data))
+		}
+
+		// The indicator is stored ahead of the value.
+		size := unsafe.Sizeof(ind) + uintptr(len(data)) + 2
+		buf := C.malloc(C.size_t(size))
+		bufs = append(bufs, buf)
+		*(*C.SQLLEN)(buf) = ind
+		value := unsafe.Add(buf, unsafe.Sizeof(ind))
+		copy(unsafe.Slice((*byte)(value), len(data)), data)
+
+		if ret := C.SQLBindCol(
+			C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(i+1),
+			ctype,
+			C.SQLPOINTER(value),
+			C.SQLLEN(len(data)),
+			(*C.SQLLEN)(buf)); !Success(ret) {
+			err := stmt.error()
+			stmt.unbind(bufs)
+			return nil, err
+		}
+	}
+	return bufs, nil
+}
+
+func (stmt *Statement) unbind(bufs []unsafe.Pointer) {
+	C.SQLFreeStmt(C.SQLHSTMT(stmt.handle), C.SQL_UNBIND)
+	for _, buf := range bufs {
+		C.free(buf)
+	}
+}
+
+// cValue converts v to the bytes of the matching C type.
+func cValue(v interface{}) (C.SQLSMALLINT, []byte, error) {
+	if t, ok := v.(time.Time); ok {
+		ts := C.SQL_TIMESTAMP_STRUCT{
+			year:     C.SQLSMALLINT(t.Year()),
+			month:    C.SQLUSMALLINT(t.Month()),
+			day:      C.SQLUSMALLINT(t.Day()),
+			hour:     C.SQLUSMALLINT(t.Hour()),
+			minute:   C.SQLUSMALLINT(t.Minute()),
+			second:   C.SQLUSMALLINT(t.Second()),
+			fraction: C.SQLUINTEGER(t.Nanosecond()),
+		}
+		return C.SQL_C_TYPE_TIMESTAMP, C.GoBytes(unsafe.Pointer(&ts), C.int(unsafe.Sizeof(ts))), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return C.SQL_C_BIT, []byte{1}, nil
+		}
+		return C.SQL_C_BIT, []byte{0}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := C.longlong(rv.Int())
+		return C.SQL_C_SBIGINT, C.GoBytes(unsafe.Pointer(&n), 8), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := C.ulonglong(rv.Uint())
+		return C.SQL_C_UBIGINT, C.GoBytes(unsafe.Pointer(&n), 8), nil
+	case reflect.Float32, reflect.Float64:
+		d := C.double(rv.Float())
+		return C.SQL_C_DOUBLE, C.GoBytes(unsafe.Pointer(&d), 8), nil
+	case reflect.String:
+		s := StringToUTF16(rv.String())
+		s = s[:len(s)-1]
+		return C.SQL_C_WCHAR, unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(s))), len(s)*2), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return C.SQL_C_BINARY, rv.Bytes(), nil
+		}
+	}
+	return 0, nil, fmt.Errorf("unsupported value type %T", v)
+}
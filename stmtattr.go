@@ -0,0 +1,187 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+*/
+import "C"
+import (
+	"context"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// SetQueryTimeout sets how long the driver waits for a statement to
+// execute before failing with SQLSTATE HYT00, rounded up to whole seconds.
+// Zero disables the timeout.
+func (stmt *Statement) SetQueryTimeout(d time.Duration) error {
+	return stmt.setAttr(C.SQL_ATTR_QUERY_TIMEOUT, seconds(d))
+}
+
+// QueryTimeout returns the statement's query timeout.
+func (stmt *Statement) QueryTimeout() (time.Duration, error) {
+	n, err := stmt.getAttr(C.SQL_ATTR_QUERY_TIMEOUT)
+	return time.Duration(n) * time.Second, err
+}
+
+// SetMaxRows limits the number of rows a query returns. Zero means no
+// limit.
+func (stmt *Statement) SetMaxRows(n int) error {
+	return stmt.setAttr(C.SQL_ATTR_MAX_ROWS, uintptr(n))
+}
+
+// MaxRows returns the statement's row limit.
+func (stmt *Statement) MaxRows() (int, error) {
+	n, err := stmt.getAttr(C.SQL_ATTR_MAX_ROWS)
+	return int(n), err
+}
+
+// SetMaxLength limits the number of bytes returned for character and
+// binary columns. Zero means no limit.
+func (stmt *Statement) SetMaxLength(n int) error {
+	return stmt.setAttr(C.SQL_ATTR_MAX_LENGTH, uintptr(n))
+}
+
+// MaxLength returns the statement's column length limit.
+func (stmt *Statement) MaxLength() (int, error) {
+	n, err := stmt.getAttr(C.SQL_ATTR_MAX_LENGTH)
+	return int(n), err
+}
+
+// SetNoScan stops the driver from scanning SQL strings for escape
+// sequences when b is true.
+func (stmt *Statement) SetNoScan(b bool) error {
+	var n uintptr = C.SQL_NOSCAN_OFF
+
+	if b {
+		n = C.SQL_NOSCAN_ON
+	}
+	return stmt.setAttr(C.SQL_ATTR_NOSCAN, n)
+}
+
+// NoScan reports whether escape sequence scanning is disabled.
+func (stmt *Statement) NoScan() (bool, error) {
+	n, err := stmt.getAttr(C.SQL_ATTR_NOSCAN)
+	return n == C.SQL_NOSCAN_ON, err
+}
+
+// ExecuteContext is like Execute but derives the query timeout from the
+// context deadline and cancels the statement when the context is done. An
+// error caused by the context matches its error with errors.Is.
+func (stmt *Statement) ExecuteContext(ctx context.Context, params ...interface{}) error {
+	var fromCtx bool
+
+	if deadline, ok := ctx.Deadline(); ok {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return context.DeadlineExceeded
+		}
+		prev, err := stmt.QueryTimeout()
+		if err == nil && (prev == 0 || d < prev) {
+			if err := stmt.SetQueryTimeout(d); err != nil {
+				Logger().Debug("godbc: cannot set query timeout from context deadline", "err", err)
+			} else {
+				fromCtx = true
+				defer stmt.SetQueryTimeout(prev)
+			}
+		}
+	}
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() { stmt.Cancel() })
+		defer stop()
+	}
+
+	return contextError(ctx, stmt.execute(ctx, params), fromCtx)
+}
+
+// applyDefaults sets the statement attributes configured by the package
+// options of the connection string.
+func (stmt *Statement) applyDefaults(cfg *config) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.queryTimeout > 0 {
+		if err := stmt.SetQueryTimeout(cfg.queryTimeout); err != nil {
+			return err
+		}
+	}
+	if cfg.maxRows > 0 {
+		if err := stmt.SetMaxRows(cfg.maxRows); err != nil {
+			return err
+		}
+	}
+	if cfg.maxLength > 0 {
+		if err := stmt.SetMaxLength(cfg.maxLength); err != nil {
+			return err
+		}
+	}
+	if cfg.noScan {
+		if err := stmt.SetNoScan(true); err != nil {
+			return err
+		}
+	}
+	if cfg.async {
+		if err := stmt.SetAsync(true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setOptions applies the statement options passed to Prepare.
+func (stmt *Statement) setOptions(opts []interface{}) error {
+	for _, opt := range opts {
+		var err error
+		switch v := opt.(type) {
+		case CursorType:
+			err = stmt.SetCursorType(v)
+		case Concurrency:
+			err = stmt.SetConcurrency(v)
+		default:
+			err = fmt.Errorf("godbc: unsupported statement option %T", opt)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stmt *Statement) setAttr(attr C.SQLINTEGER, value uintptr) error {
+	if ret := C.SQLSetStmtAttr(
+		C.SQLHSTMT(stmt.handle),
+		attr,
+		C.SQLPOINTER(unsafe.Pointer(value)),
+		C.SQL_IS_UINTEGER); !Success(ret) {
+		return stmt.error()
+	}
+	return nil
+}
+
+func (stmt *Statement) getAttr(attr C.SQLINTEGER) (uint64, error) {
+	var value C.SQLULEN
+
+	if ret := C.SQLGetStmtAttr(
+		C.SQLHSTMT(stmt.handle),
+		attr,
+		C.SQLPOINTER(unsafe.Pointer(&value)),
+		C.SQL_IS_UINTEGER,
+		nil); !Success(ret) {
+		return 0, stmt.error()
+	}
+	return uint64(value), nil
+}
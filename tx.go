@@ -0,0 +1,111 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Tx is a transaction on a Connection, see BeginTx.
+type Tx struct {
+	conn      *Connection
+	isolation int  // to restore, 0 if unchanged.
+	readOnly  bool // the access mode was set to read-only.
+	done      bool
+}
+
+var errTxDone = errors.New("godbc: transaction has already been committed or rolled back")
+
+// BeginTx turns autocommit off to start a transaction with the given
+// isolation level, one of the Txn* constants or 0 for the connection's
+// default. Committing or rolling back the transaction turns autocommit
+// back on and restores the isolation level and access mode.
+func (conn *Connection) BeginTx(isolation int, readOnly bool) (*Tx, error) {
+	tx := &Tx{conn: conn}
+
+	if isolation != 0 {
+		prev, err := conn.TxnIsolation()
+		if err != nil {
+			return nil, err
+		}
+		if prev != isolation {
+			if err := conn.SetTxnIsolation(isolation); err != nil {
+				return nil, err
+			}
+			tx.isolation = prev
+		}
+	}
+	if readOnly {
+		if err := conn.SetReadOnly(true); err != nil {
+			tx.restore()
+			return nil, err
+		}
+		tx.readOnly = true
+	}
+	if err := conn.AutoCommit(false); err != nil {
+		tx.restore()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Commit commits the transaction.
+func (tx *Tx) Commit() error {
+	return tx.end(tx.conn.Commit)
+}
+
+// Rollback rolls the transaction back.
+func (tx *Tx) Rollback() error {
+	return tx.end(tx.conn.Rollback)
+}
+
+func (tx *Tx) end(endTran func() error) error {
+	if tx.done {
+		return errTxDone
+	}
+	if err := endTran(); err != nil {
+		return err
+	}
+	tx.done = true
+	if err := tx.conn.AutoCommit(true); err != nil {
+		return err
+	}
+	return tx.restore()
+}
+
+// restore puts back the connection attributes BeginTx changed.
+func (tx *Tx) restore() error {
+	var err error
+
+	if tx.readOnly {
+		err = tx.conn.SetReadOnly(false)
+	}
+	if tx.isolation != 0 {
+		if e := tx.conn.SetTxnIsolation(tx.isolation); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// TxnIsolationOf returns the Txn* constant for a database/sql isolation
+// level, 0 for sql.LevelDefault.
+func TxnIsolationOf(level sql.IsolationLevel) (int, error) {
+	switch level {
+	case sql.LevelDefault:
+		return 0, nil
+	case sql.LevelReadUncommitted:
+		return TxnReadUncommitted, nil
+	case sql.LevelReadCommitted:
+		return TxnReadCommitted, nil
+	case sql.LevelRepeatableRead:
+		return TxnRepeatableRead, nil
+	case sql.LevelSerializable:
+		return TxnSerializable, nil
+	}
+	return 0, fmt.Errorf("godbc: unsupported isolation level %v", level)
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2011, Wei guangjing <vcc.163@gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package godbc
+
+/*
+#include <stdlib.h>
+
+#ifdef __MINGW32__
+  #include <windef.h>
+#else
+  typedef void* HANDLE;
+#endif
+
+#include <sql.h>
+#include <sqlext.h>
+#include <sqltypes.h>
+
+SQLRETURN _SQLColAttribute(SQLHSTMT, SQLUSMALLINT, SQLUSMALLINT, SQLPOINTER, SQLSMALLINT, SQLSMALLINT*, void*);
+*/
+import "C"
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// TypeInfo describes a data type supported by the data source, as
+// returned by SQLGetTypeInfo.
+type TypeInfo struct {
+	Name              string // DBMS specific type name, e.g. "varchar".
+	DataType          int    // SQL data type code.
+	ColumnSize        int
+	LiteralPrefix     string
+	LiteralSuffix     string
+	CreateParams      string // e.g. "max length" or "precision,scale".
+	Nullable          int
+	CaseSensitive     bool
+	Searchable        int // SQL_PRED_NONE, SQL_PRED_CHAR, SQL_PRED_BASIC or SQL_SEARCHABLE.
+	Unsigned          bool
+	FixedPrecScale    bool
+	AutoUnique        bool
+	LocalName         string
+	MinScale          int
+	MaxScale          int
+	SQLDataType       int
+	DatetimeSubtype   int
+	Radix             int
+	IntervalPrecision int
+}
+
+// Declare returns the type as it is spelled in a column definition, with
+// params filling the type's create parameters, e.g. "varchar(20)" or
+// "decimal(10,2)". Extra params are ignored.
+func (t *TypeInfo) Declare(params ...int) string {
+	if t.CreateParams == "" || len(params) == 0 {
+		return t.Name
+	}
+	n := len(strings.Split(t.CreateParams, ","))
+	if len(params) > n {
+		params = params[:n]
+	}
+	s := make([]string, len(params))
+	for i, p := range params {
+		s[i] = strconv.Itoa(p)
+	}
+	return t.Name + "(" + strings.Join(s, ",") + ")"
+}
+
+// TypeInfo returns the data types supported by the data source, ordered by
+// SQL data type code and then by how closely the type maps to it. The result
+// is cached for the life of the connection.
+func (conn *Connection) TypeInfo() ([]*TypeInfo, error) {
+	conn.typeInfoMu.Lock()
+	defer conn.typeInfoMu.Unlock()
+
+	if conn.typeInfo != nil {
+		return conn.typeInfo, nil
+	}
+
+	types := []*TypeInfo{}
+	getTypeInfo := func(h C.SQLHSTMT) C.SQLRETURN {
+		return C.SQLGetTypeInfo(h, C.SQL_ALL_TYPES)
+	}
+	err := conn.catalog(getTypeInfo, getTypeInfo, func(stmt *Statement) error {
+		t := &TypeInfo{}
+		if err := stmt.scan(
+			&t.Name, &t.DataType, &t.ColumnSize, &t.LiteralPrefix,
+			&t.LiteralSuffix, &t.CreateParams, &t.Nullable, &t.CaseSensitive,
+			&t.Searchable, &t.Unsigned, &t.FixedPrecScale, &t.AutoUnique,
+			&t.LocalName, &t.MinScale, &t.MaxScale, &t.SQLDataType,
+			&t.DatetimeSubtype, &t.Radix, &t.IntervalPrecision); err != nil {
+			return err
+		}
+		types = append(types, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	conn.typeInfo = types
+	return types, nil
+}
+
+// LookupType returns the native type best matching the SQL data type code
+// dataType, or nil if the data source has none.
+func (conn *Connection) LookupType(dataType int) (*TypeInfo, error) {
+	types, err := conn.TypeInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		if t.DataType == dataType {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// DatabaseTypeName returns the native type name of the result column col
+// (starting at 1), as given by the SQL_DESC_TYPE_NAME column attribute.
+// Drivers leaving it empty get the name from the connection's type catalog
+// instead, which only knows the SQL type of the column and may name
+// another native type mapped to it.
+func (stmt *Statement) DatabaseTypeName(col int) (string, error) {
+	var (
+		nameLen C.SQLSMALLINT
+		name    = make([]byte, infoBufferLen)
+	)
+
+	if err := stmt.checkOpen("get type name"); err != nil {
+		return "", err
+	}
+
+	if ret := stmt.wait(func() C.SQLRETURN {
+		return C._SQLColAttribute(
+			C.SQLHSTMT(stmt.handle),
+			C.SQLUSMALLINT(col),
+			C.SQL_DESC_TYPE_NAME,
+			C.SQLPOINTER(unsafe.Pointer(&name[0])),
+			C.SQLSMALLINT(len(name)),
+			&nameLen,
+			nil)
+	}); !Success(ret) {
+		return "", stmt.error()
+	}
+	if int(nameLen) >= len(name) {
+		nameLen = C.SQLSMALLINT(len(name) - 1)
+	}
+	if nameLen > 0 {
+		return string(name[:nameLen]), nil
+	}
+
+	f, err := stmt.FieldMetadata(col)
+	if err != nil {
+		return "", err
+	}
+	if t, err := stmt.conn.LookupType(f.Type); err == nil && t != nil {
+		return t.Name, nil
+	}
+	return "", nil
+}